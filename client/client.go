@@ -0,0 +1,322 @@
+// Package client is a typed client of contract-caller server, it shares request and response types with the server
+// through package common.
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/KyberNetwork/contract-caller/common"
+	libhttp "github.com/KyberNetwork/contract-caller/lib/http"
+)
+
+// Error is an error response of the server, code is one of validation, not_found, revert, upstream,
+// timeout, internal, unauthorized, forbidden and rate_limited
+type Error struct {
+	StatusCode int                    `json:"-"`
+	Message    string                 `json:"err"`
+	Code       string                 `json:"code"`
+	Details    map[string]interface{} `json:"details"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("contract-caller: %s (code=%s, status=%d)", e.Message, e.Code, e.StatusCode)
+}
+
+// Client calls contract-caller server at base url
+type Client struct {
+	baseURL string
+	rc      *libhttp.RestClient
+}
+
+// New returns client of server at baseURL, a default http client is used when c is nil
+func New(baseURL string, c *http.Client) *Client {
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		rc:      libhttp.NewRestClient(c),
+	}
+}
+
+// WithHeader sets a header sent with every request, e.g. an api key
+func (c *Client) WithHeader(key, value string) *Client {
+	c.rc.WithHeader(key, value)
+	return c
+}
+
+// envelope is body of server responses
+type envelope struct {
+	Data     interface{}            `json:"data"`
+	Contract *common.ContractSource `json:"contract"`
+	Err      string                 `json:"err"`
+	Code     string                 `json:"code"`
+	Details  map[string]interface{} `json:"details"`
+}
+
+// do sends a request and decodes the response envelope into rsp, query is a struct with form tags
+func (c *Client) do(ctx context.Context, method, path string, query, body interface{}, rsp *envelope) error {
+	req := libhttp.Request{
+		Method: method,
+		URL:    c.baseURL + path,
+		Query:  queryValues(query),
+		Body:   body,
+	}
+	err := c.rc.Do(ctx, req, rsp)
+	var httpErr *libhttp.HTTPError
+	if errors.As(err, &httpErr) {
+		e := &Error{StatusCode: httpErr.StatusCode, Message: string(httpErr.Body)}
+		_ = json.Unmarshal(httpErr.Body, e)
+		return e
+	}
+	if err != nil {
+		return err
+	}
+	if rsp.Err != "" {
+		return &Error{StatusCode: http.StatusOK, Message: rsp.Err, Code: rsp.Code, Details: rsp.Details}
+	}
+	return nil
+}
+
+// request sends a request and decodes data of the response into result
+func (c *Client) request(ctx context.Context, method, path string, query, body, result interface{}) error {
+	return c.do(ctx, method, path, query, body, &envelope{Data: result})
+}
+
+// queryValues encodes non zero fields of a struct with form tags
+func queryValues(query interface{}) url.Values {
+	if query == nil {
+		return nil
+	}
+	values := make(url.Values)
+	v := reflect.ValueOf(query)
+	for i := 0; i < v.NumField(); i++ {
+		name := strings.Split(v.Type().Field(i).Tag.Get("form"), ",")[0]
+		f := v.Field(i)
+		if name == "" || f.IsZero() {
+			continue
+		}
+		switch f.Kind() {
+		case reflect.Int, reflect.Int64:
+			values.Set(name, strconv.FormatInt(f.Int(), 10))
+		default:
+			values.Set(name, fmt.Sprint(f.Interface()))
+		}
+	}
+	return values
+}
+
+// Methods returns methods of a contract, and metadata of the contract when it is verified
+func (c *Client) Methods(ctx context.Context, req common.MethodsRequest) ([]common.Method, *common.ContractSource, error) {
+	var methods []common.Method
+	rsp := envelope{Data: &methods}
+	if err := c.do(ctx, http.MethodPost, "/contract/methods", nil, req, &rsp); err != nil {
+		return nil, nil, err
+	}
+	return methods, rsp.Contract, nil
+}
+
+// Call calls a view method, result is raw json of the outputs
+func (c *Client) Call(ctx context.Context, req common.CallContractRequest) (json.RawMessage, error) {
+	var result json.RawMessage
+	err := c.request(ctx, http.MethodPost, "/contract/call", nil, req, &result)
+	return result, err
+}
+
+// Multicall calls methods of several contracts at one block
+func (c *Client) Multicall(ctx context.Context, req common.MulticallRequest) ([]common.CallResult, error) {
+	var result []common.CallResult
+	err := c.request(ctx, http.MethodPost, "/contract/multicall", nil, req, &result)
+	return result, err
+}
+
+// Diff diffs view methods of a contract between two blocks
+func (c *Client) Diff(ctx context.Context, req common.DiffRequest) ([]common.StateDiff, error) {
+	var result []common.StateDiff
+	err := c.request(ctx, http.MethodPost, "/contract/diff", nil, req, &result)
+	return result, err
+}
+
+// Pipeline runs calls referencing outputs of earlier calls
+func (c *Client) Pipeline(ctx context.Context, req common.PipelineRequest) (common.PipelineResult, error) {
+	var result common.PipelineResult
+	err := c.request(ctx, http.MethodPost, "/contract/pipeline", nil, req, &result)
+	return result, err
+}
+
+// Storage reads storage slots and state variables of a contract
+func (c *Client) Storage(ctx context.Context, req common.StorageRequest) (common.StorageResult, error) {
+	var result common.StorageResult
+	err := c.request(ctx, http.MethodPost, "/contract/storage", nil, req, &result)
+	return result, err
+}
+
+// NetworkInfo returns network of a node, empty node means the default node
+func (c *Client) NetworkInfo(ctx context.Context, node string) (string, error) {
+	var result string
+	err := c.request(ctx, http.MethodGet, "/contract/network-info", common.NetworkInfoQuery{Node: node}, nil, &result)
+	return result, err
+}
+
+// Networks returns networks known by the server
+func (c *Client) Networks(ctx context.Context) ([]common.Network, error) {
+	var result []common.Network
+	err := c.request(ctx, http.MethodGet, "/contract/networks", nil, nil, &result)
+	return result, err
+}
+
+// Interfaces returns abi of standard interfaces by name
+func (c *Client) Interfaces(ctx context.Context) (map[string]json.RawMessage, error) {
+	var result map[string]json.RawMessage
+	err := c.request(ctx, http.MethodGet, "/contract/interfaces", nil, nil, &result)
+	return result, err
+}
+
+// DetectInterfaces returns standard interfaces implemented by a contract
+func (c *Client) DetectInterfaces(ctx context.Context, contract string, q common.NodeQuery) ([]common.InterfaceMatch, error) {
+	var result []common.InterfaceMatch
+	err := c.request(ctx, http.MethodGet, "/contract/interfaces/"+url.PathEscape(contract), q, nil, &result)
+	return result, err
+}
+
+// Batch runs a batch of calls
+func (c *Client) Batch(ctx context.Context, b common.Batch) ([]common.BatchResult, error) {
+	var result []common.BatchResult
+	err := c.request(ctx, http.MethodPost, "/batch", nil, b, &result)
+	return result, err
+}
+
+// TokenInfo returns metadata of a token
+func (c *Client) TokenInfo(ctx context.Context, token string, q common.TokenQuery) (common.TokenInfo, error) {
+	var result common.TokenInfo
+	err := c.request(ctx, http.MethodGet, "/token/"+url.PathEscape(token), q, nil, &result)
+	return result, err
+}
+
+// TokenBalance returns balance of q.Owner, q.ID is required for erc1155 tokens
+func (c *Client) TokenBalance(ctx context.Context, token string, q common.TokenQuery) (common.TokenAmount, error) {
+	var result common.TokenAmount
+	err := c.request(ctx, http.MethodGet, "/token/"+url.PathEscape(token)+"/balance", q, nil, &result)
+	return result, err
+}
+
+// TokenAllowance returns allowance of q.Owner to q.Spender
+func (c *Client) TokenAllowance(ctx context.Context, token string, q common.TokenQuery) (common.TokenAmount, error) {
+	var result common.TokenAmount
+	err := c.request(ctx, http.MethodGet, "/token/"+url.PathEscape(token)+"/allowance", q, nil, &result)
+	return result, err
+}
+
+// Balances returns balances of holders for tokens
+func (c *Client) Balances(ctx context.Context, req common.BalancesRequest) (common.BalanceMatrix, error) {
+	var result common.BalanceMatrix
+	err := c.request(ctx, http.MethodPost, "/token/balances", nil, req, &result)
+	return result, err
+}
+
+// CreateAlert creates an alert and returns its id
+func (c *Client) CreateAlert(ctx context.Context, a common.Alert) (int64, error) {
+	var id int64
+	err := c.request(ctx, http.MethodPost, "/alert", nil, a, &id)
+	return id, err
+}
+
+// Alerts returns all alerts
+func (c *Client) Alerts(ctx context.Context) ([]common.Alert, error) {
+	var result []common.Alert
+	err := c.request(ctx, http.MethodGet, "/alert", nil, nil, &result)
+	return result, err
+}
+
+// DeleteAlert deletes an alert
+func (c *Client) DeleteAlert(ctx context.Context, id int64) error {
+	return c.request(ctx, http.MethodDelete, "/alert/"+strconv.FormatInt(id, 10), nil, nil, nil)
+}
+
+// SaveQuery saves a query
+func (c *Client) SaveQuery(ctx context.Context, q common.SavedQuery) (common.SavedQuery, error) {
+	var result common.SavedQuery
+	err := c.request(ctx, http.MethodPost, "/query", nil, q, &result)
+	return result, err
+}
+
+// UpdateQuery updates a saved query
+func (c *Client) UpdateQuery(ctx context.Context, id string, q common.SavedQuery) (common.SavedQuery, error) {
+	var result common.SavedQuery
+	err := c.request(ctx, http.MethodPut, "/query/"+url.PathEscape(id), nil, q, &result)
+	return result, err
+}
+
+// SearchQueries returns saved queries matching a search
+func (c *Client) SearchQueries(ctx context.Context, q common.QuerySearch) ([]common.SavedQuery, error) {
+	var result []common.SavedQuery
+	err := c.request(ctx, http.MethodGet, "/query", q, nil, &result)
+	return result, err
+}
+
+// Query returns a saved query
+func (c *Client) Query(ctx context.Context, id string) (common.SavedQuery, error) {
+	var result common.SavedQuery
+	err := c.request(ctx, http.MethodGet, "/query/"+url.PathEscape(id), nil, nil, &result)
+	return result, err
+}
+
+// DeleteQuery deletes a saved query
+func (c *Client) DeleteQuery(ctx context.Context, id string) error {
+	return c.request(ctx, http.MethodDelete, "/query/"+url.PathEscape(id), nil, nil, nil)
+}
+
+// RunQuery runs a saved query, result is raw json of the outputs
+func (c *Client) RunQuery(ctx context.Context, id string, q common.RunQueryQuery) (json.RawMessage, error) {
+	var result json.RawMessage
+	err := c.request(ctx, http.MethodPost, "/query/"+url.PathEscape(id)+"/run", q, nil, &result)
+	return result, err
+}
+
+// History returns recorded calls
+func (c *Client) History(ctx context.Context, q common.HistoryQuery) ([]common.CallHistory, error) {
+	var result []common.CallHistory
+	err := c.request(ctx, http.MethodGet, "/history", q, nil, &result)
+	return result, err
+}
+
+// Replay re-executes a recorded call
+func (c *Client) Replay(ctx context.Context, id int64, q common.ReplayQuery) (common.Replay, error) {
+	var result common.Replay
+	err := c.request(ctx, http.MethodPost, "/history/"+strconv.FormatInt(id, 10)+"/replay", q, nil, &result)
+	return result, err
+}
+
+// ABIAuditLog returns latest abi writes, it needs an admin api key
+func (c *Client) ABIAuditLog(ctx context.Context, q common.ABIAuditQuery) ([]common.ABIAudit, error) {
+	var result []common.ABIAudit
+	err := c.request(ctx, http.MethodGet, "/abi/audit", q, nil, &result)
+	return result, err
+}
+
+// ABIVersions returns abi versions of a contract, latest first
+func (c *Client) ABIVersions(ctx context.Context, contract string) ([]common.ABIVersion, error) {
+	var result []common.ABIVersion
+	err := c.request(ctx, http.MethodGet, "/abi/versions/"+url.PathEscape(contract), nil, nil, &result)
+	return result, err
+}
+
+// RollbackABI stores an earlier abi version of a contract again, it needs an editor api key
+func (c *Client) RollbackABI(ctx context.Context, contract string, version int64) (common.ABIVersion, error) {
+	var result common.ABIVersion
+	err := c.request(ctx, http.MethodPost, "/abi/rollback/"+url.PathEscape(contract), nil,
+		common.ABIRollbackRequest{Version: version}, &result)
+	return result, err
+}
+
+// DiffABI returns added, removed and changed functions, events and errors between two abi versions
+func (c *Client) DiffABI(ctx context.Context, contract string, q common.ABIDiffQuery) (common.ABIDiff, error) {
+	var result common.ABIDiff
+	err := c.request(ctx, http.MethodGet, "/abi/diff/"+url.PathEscape(contract), q, nil, &result)
+	return result, err
+}
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/KyberNetwork/contract-caller/common"
+)
+
+func TestClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/contract/methods":
+			var req common.MethodsRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			require.Equal(t, "0x01", req.Contract)
+			_, _ = w.Write([]byte(`{"data":[{"name":"symbol","arguments":[]}],"contract":{"name":"Token"}}`))
+		case "/token/0x02/balance":
+			require.Equal(t, "0x03", r.URL.Query().Get("owner"))
+			require.Empty(t, r.URL.Query().Get("spender"))
+			_, _ = w.Write([]byte(`{"data":{"raw":"1000","decimals":3}}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"err":"contract is not a valid ethereum address","code":"validation",` +
+				`"details":{"argument":"contract"}}`))
+		}
+	}))
+	defer srv.Close()
+	c := New(srv.URL, nil)
+	ctx := context.Background()
+
+	methods, source, err := c.Methods(ctx, common.MethodsRequest{Contract: "0x01"})
+	require.NoError(t, err)
+	require.Equal(t, []common.Method{{Name: "symbol", Arguments: []common.Argument{}}}, methods)
+	require.Equal(t, "Token", source.Name)
+
+	amount, err := c.TokenBalance(ctx, "0x02", common.TokenQuery{Owner: "0x03"})
+	require.NoError(t, err)
+	require.Equal(t, "1000", amount.Raw)
+	require.Equal(t, uint8(3), amount.Decimals)
+
+	_, err = c.Call(ctx, common.CallContractRequest{Contract: "bad", Method: "symbol"})
+	e, ok := err.(*Error)
+	require.True(t, ok, err)
+	require.Equal(t, http.StatusBadRequest, e.StatusCode)
+	require.Equal(t, "validation", e.Code)
+	require.Equal(t, "contract", e.Details["argument"])
+}
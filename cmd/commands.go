@@ -0,0 +1,721 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/urfave/cli"
+
+	"github.com/KyberNetwork/contract-caller/common"
+	"github.com/KyberNetwork/contract-caller/core"
+	"github.com/KyberNetwork/contract-caller/lib/render"
+	"github.com/KyberNetwork/contract-caller/storage"
+)
+
+var (
+	contractFlag   = "contract"
+	abiFileFlag    = "abi-file"
+	networkFlag    = "network"
+	methodFlag     = "method"
+	paramFlag      = "param"
+	paramsFileFlag = "params-file"
+	blockFlag      = "block"
+	customNodeFlag = "custom-node"
+	formatFlag     = "format"
+	callsFileFlag  = "calls-file"
+	dataFlag       = "data"
+	fileFlag       = "file"
+	outFlag        = "out"
+	holderFlag     = "holder"
+	tokenFlag      = "token"
+	spenderFlag    = "spender"
+	interfaceFlag  = "interface"
+	limitFlag      = "limit"
+	versionFlag    = "version"
+	fromFlag       = "from"
+	toFlag         = "to"
+)
+
+var (
+	contractCliFlag = cli.StringFlag{
+		Name:  contractFlag,
+		Usage: "contract address",
+	}
+	abiFileCliFlag = cli.StringFlag{
+		Name:  abiFileFlag,
+		Usage: "path of abi json file, stored abi or etherscan abi is used if empty",
+	}
+	interfaceCliFlag = cli.StringFlag{
+		Name:  interfaceFlag,
+		Usage: "standard interface used as abi when no abi file is given, e.g. erc20, erc4626, gnosis-safe",
+	}
+	networkCliFlag = cli.StringFlag{
+		Name:  networkFlag,
+		Usage: "network name used to look up abi on etherscan",
+		Value: common.EthereumMainnet,
+	}
+	blockCliFlag = cli.StringFlag{
+		Name:  blockFlag,
+		Usage: "block number, decimal or hex, default is latest",
+	}
+	customNodeCliFlag = cli.StringFlag{
+		Name:  customNodeFlag,
+		Usage: "node used instead of the default one",
+	}
+	formatCliFlag = cli.StringFlag{
+		Name:  formatFlag,
+		Usage: "output format: table, json, jsonl, csv or markdown",
+		Value: render.FormatTable,
+	}
+)
+
+func commands() []cli.Command {
+	return []cli.Command{
+		{
+			Name:   "methods",
+			Usage:  "list view methods of a contract",
+			Action: methodsCmd,
+			Flags:  []cli.Flag{contractCliFlag, abiFileCliFlag, interfaceCliFlag, networkCliFlag, formatCliFlag},
+		},
+		{
+			Name:   "interfaces",
+			Usage:  "detect standard interfaces implemented by a contract",
+			Action: interfacesCmd,
+			Flags:  []cli.Flag{contractCliFlag, blockCliFlag, customNodeCliFlag, formatCliFlag},
+		},
+		{
+			Name:   "call",
+			Usage:  "call a view method of a contract",
+			Action: callCmd,
+			Flags: []cli.Flag{contractCliFlag, abiFileCliFlag, interfaceCliFlag, networkCliFlag, blockCliFlag,
+				customNodeCliFlag, formatCliFlag,
+				cli.StringFlag{
+					Name:  methodFlag,
+					Usage: "method name",
+				},
+				cli.StringSliceFlag{
+					Name:  paramFlag,
+					Usage: "method param as name=value, can be repeated",
+				},
+				cli.StringFlag{
+					Name:  paramsFileFlag,
+					Usage: "path of json file with params as {\"name\": \"value\"}",
+				},
+			},
+		},
+		{
+			Name:   "multicall",
+			Usage:  "execute calls from a json file at the same block",
+			Action: multicallCmd,
+			Flags: []cli.Flag{blockCliFlag, customNodeCliFlag, formatCliFlag,
+				cli.StringFlag{
+					Name:  callsFileFlag,
+					Usage: "path of json file with a list of {contract, abi, method, params}",
+				},
+			},
+		},
+		{
+			Name:   "balances",
+			Usage:  "print balances of holders for tokens with totals",
+			Action: balancesCmd,
+			Flags: []cli.Flag{blockCliFlag, customNodeCliFlag, formatCliFlag,
+				cli.StringSliceFlag{
+					Name:  holderFlag,
+					Usage: "holder address, can be repeated",
+				},
+				cli.StringSliceFlag{
+					Name:  tokenFlag,
+					Usage: "token address or \"native\" for ETH/BNB, can be repeated",
+				},
+				cli.StringSliceFlag{
+					Name:  spenderFlag,
+					Usage: "spender address to print allowances of holders for, can be repeated",
+				},
+			},
+		},
+		{
+			Name:   "run",
+			Usage:  "run a batch file (yaml or json) and print a consolidated report",
+			Action: runBatchCmd,
+			Flags: []cli.Flag{formatCliFlag,
+				cli.StringFlag{
+					Name:  fileFlag,
+					Usage: "path of batch file",
+				},
+				cli.StringFlag{
+					Name:  outFlag,
+					Usage: "output path, default is stdout",
+				},
+			},
+		},
+		{
+			Name:  "abi",
+			Usage: "manage stored abis",
+			Subcommands: []cli.Command{
+				{
+					Name:   "get",
+					Usage:  "print stored abi of a contract",
+					Action: abiGetCmd,
+					Flags:  []cli.Flag{contractCliFlag},
+				},
+				{
+					Name:   "set",
+					Usage:  "store abi of a contract, or abi of a compiler artifact",
+					Action: abiSetCmd,
+					Flags:  []cli.Flag{contractCliFlag, abiFileCliFlag},
+				},
+				{
+					Name:   "export",
+					Usage:  "export all stored abis as {\"contract\": \"abi\"} json",
+					Action: abiExportCmd,
+					Flags: []cli.Flag{cli.StringFlag{
+						Name:  fileFlag,
+						Usage: "output path, default is stdout",
+					}},
+				},
+				{
+					Name:   "import",
+					Usage:  "import abis from {\"contract\": \"abi\"} json",
+					Action: abiImportCmd,
+					Flags: []cli.Flag{cli.StringFlag{
+						Name:  fileFlag,
+						Usage: "input path",
+					}},
+				},
+				{
+					Name:   "versions",
+					Usage:  "list stored abi versions of a contract",
+					Action: abiVersionsCmd,
+					Flags:  []cli.Flag{contractCliFlag, formatCliFlag},
+				},
+				{
+					Name:   "rollback",
+					Usage:  "store an earlier abi version of a contract again",
+					Action: abiRollbackCmd,
+					Flags: []cli.Flag{contractCliFlag, cli.Int64Flag{
+						Name:  versionFlag,
+						Usage: "id of the abi version",
+					}},
+				},
+				{
+					Name:   "diff",
+					Usage:  "list added, removed and changed functions, events and errors between two abi versions",
+					Action: abiDiffCmd,
+					Flags: []cli.Flag{contractCliFlag, cli.Int64Flag{
+						Name:  fromFlag,
+						Usage: "id of the older version, default is the version before --to",
+					}, cli.Int64Flag{
+						Name:  toFlag,
+						Usage: "id of the newer version, default is the latest version",
+					}, formatCliFlag},
+				},
+				{
+					Name:   "audit",
+					Usage:  "list latest abi writes with their actor and previous abi",
+					Action: abiAuditCmd,
+					Flags: []cli.Flag{cli.StringFlag{
+						Name:  contractFlag,
+						Usage: "contract address, all contracts if empty",
+					}, cli.IntFlag{
+						Name:  limitFlag,
+						Usage: "max number of writes",
+						Value: 50,
+					}, formatCliFlag},
+				},
+			},
+		},
+		keysCommand(),
+		{
+			Name:   "console",
+			Usage:  "interactive console to explore a contract",
+			Action: consoleCmd,
+			Flags:  []cli.Flag{contractCliFlag, abiFileCliFlag, networkCliFlag},
+		},
+		{
+			Name:   "decode",
+			Usage:  "decode calldata, or return data of a method",
+			Action: decodeCmd,
+			Flags: []cli.Flag{contractCliFlag, abiFileCliFlag, formatCliFlag,
+				cli.StringFlag{
+					Name:  dataFlag,
+					Usage: "hex data",
+				},
+				cli.StringFlag{
+					Name:  methodFlag,
+					Usage: "decode data as return data of this method instead of calldata",
+				},
+			},
+		},
+	}
+}
+
+// newStorage opens storage from global flags
+func newStorage(c *cli.Context) (*storage.Storage, error) {
+	return storage.NewStorage(c.GlobalString(dbPathFlag))
+}
+
+// newCore builds core from global flags
+func newCore(c *cli.Context) (*core.Core, error) {
+	esc, err := newEtherscan(c)
+	if err != nil {
+		return nil, err
+	}
+	ecli, err := ethclient.Dial(c.GlobalString(nodeFlag))
+	if err != nil {
+		return nil, err
+	}
+	str, err := newStorage(c)
+	if err != nil {
+		return nil, err
+	}
+	coreInstance, err := core.NewCore(esc, ecli, str)
+	if err != nil {
+		return nil, err
+	}
+	// the cli runs on the machine of its user, local and private nodes are fine
+	if err := coreInstance.SetNodePolicy(core.NodePolicy{AllowPrivate: true}); err != nil {
+		return nil, err
+	}
+	return coreInstance, nil
+}
+
+func contractArg(c *cli.Context) (ethereum.Address, error) {
+	contract := c.String(contractFlag)
+	if !ethereum.IsHexAddress(contract) {
+		return ethereum.Address{}, fmt.Errorf("contract is not a valid ethereum address, contract=%s", contract)
+	}
+	return ethereum.HexToAddress(contract), nil
+}
+
+func readABIFile(c *cli.Context) (string, error) {
+	path := c.String(abiFileFlag)
+	if path == "" {
+		if iface := c.String(interfaceFlag); iface != "" {
+			return core.StandardABI(iface)
+		}
+		return "", nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// readParams reads params from params file, then overrides them with --param flags
+func readParams(c *cli.Context) (map[string]interface{}, error) {
+	params := make(map[string]interface{})
+	if path := c.String(paramsFileFlag); path != "" {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &params); err != nil {
+			return nil, fmt.Errorf("cannot parse params file, err: %s", err.Error())
+		}
+	}
+	for _, p := range c.StringSlice(paramFlag) {
+		kv := strings.SplitN(p, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("param must be name=value, param=%s", p)
+		}
+		params[kv[0]] = kv[1]
+	}
+	return params, nil
+}
+
+func methodsCmd(c *cli.Context) error {
+	contract, err := contractArg(c)
+	if err != nil {
+		return err
+	}
+	contractABI, err := readABIFile(c)
+	if err != nil {
+		return err
+	}
+	coreInstance, err := newCore(c)
+	if err != nil {
+		return err
+	}
+	methods, err := coreInstance.ContractMethods(contract, contractABI, "", c.String(networkFlag))
+	if err != nil {
+		return err
+	}
+	sort.Slice(methods, func(i, j int) bool {
+		return methods[i].Name < methods[j].Name
+	})
+	t := render.Table{Header: []string{"method", "arguments", "doc"}}
+	for _, m := range methods {
+		var args []string
+		for _, a := range m.Arguments {
+			args = append(args, strings.TrimSpace(a.Type+" "+a.Name))
+		}
+		t.Rows = append(t.Rows, []string{m.Name, strings.Join(args, ", "), m.Doc})
+	}
+	return render.Write(os.Stdout, c.String(formatFlag), t, methods)
+}
+
+func callCmd(c *cli.Context) error {
+	contract, err := contractArg(c)
+	if err != nil {
+		return err
+	}
+	contractABI, err := readABIFile(c)
+	if err != nil {
+		return err
+	}
+	params, err := readParams(c)
+	if err != nil {
+		return err
+	}
+	coreInstance, err := newCore(c)
+	if err != nil {
+		return err
+	}
+	if contractABI == "" {
+		if contractABI, err = coreInstance.ContractABI(contract, c.String(networkFlag)); err != nil {
+			return err
+		}
+	}
+	result, err := coreInstance.CallContract(contract, contractABI, c.String(methodFlag), c.String(blockFlag),
+		params, c.String(customNodeFlag))
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+	return render.Write(os.Stdout, c.String(formatFlag), render.CallTable(result), result)
+}
+
+func multicallCmd(c *cli.Context) error {
+	data, err := ioutil.ReadFile(c.String(callsFileFlag))
+	if err != nil {
+		return err
+	}
+	var calls []common.CallRequest
+	if err := json.Unmarshal(data, &calls); err != nil {
+		return fmt.Errorf("cannot parse calls file, err: %s", err.Error())
+	}
+	coreInstance, err := newCore(c)
+	if err != nil {
+		return err
+	}
+	results, err := coreInstance.Multicall(calls, c.String(blockFlag), c.String(customNodeFlag))
+	if err != nil {
+		return err
+	}
+	if err := render.Write(os.Stdout, c.String(formatFlag), render.MulticallTable(calls, results), results); err != nil {
+		return err
+	}
+	failed := 0
+	for _, r := range results {
+		if r.Err != "" {
+			failed++
+		}
+	}
+	if failed != 0 {
+		return cli.NewExitError(fmt.Sprintf("%d of %d calls failed", failed, len(calls)), 1)
+	}
+	return nil
+}
+
+func abiGetCmd(c *cli.Context) error {
+	contract, err := contractArg(c)
+	if err != nil {
+		return err
+	}
+	str, err := newStorage(c)
+	if err != nil {
+		return err
+	}
+	contractABI, err := str.GetContractABI(contract)
+	if err != nil {
+		return err
+	}
+	if contractABI == "" {
+		return cli.NewExitError(fmt.Sprintf("no abi stored for contract %s", contract.Hex()), 1)
+	}
+	fmt.Println(contractABI)
+	return nil
+}
+
+func abiSetCmd(c *cli.Context) error {
+	contract, err := contractArg(c)
+	if err != nil {
+		return err
+	}
+	contractABI, err := readABIFile(c)
+	if err != nil {
+		return err
+	}
+	if contractABI == "" {
+		return fmt.Errorf("%s is required", abiFileFlag)
+	}
+	str, err := newStorage(c)
+	if err != nil {
+		return err
+	}
+	contractABI, source := core.ABIFromArtifact(contractABI)
+	return str.StoreContractABI(contract, contractABI, source, cliActor())
+}
+
+// cliActor identifies abi writes of the cli in the audit log
+func cliActor() string {
+	if u, err := user.Current(); err == nil {
+		return "cli@" + u.Username
+	}
+	return "cli"
+}
+
+func abiExportCmd(c *cli.Context) error {
+	str, err := newStorage(c)
+	if err != nil {
+		return err
+	}
+	abis, err := str.GetContractABIs()
+	if err != nil {
+		return err
+	}
+	out := os.Stdout
+	if path := c.String(fileFlag); path != "" {
+		if out, err = os.Create(path); err != nil {
+			return err
+		}
+		defer func() {
+			_ = out.Close()
+		}()
+	}
+	return render.WriteJSON(out, abis)
+}
+
+func abiImportCmd(c *cli.Context) error {
+	data, err := ioutil.ReadFile(c.String(fileFlag))
+	if err != nil {
+		return err
+	}
+	var abis map[string]string
+	if err := json.Unmarshal(data, &abis); err != nil {
+		return fmt.Errorf("cannot parse abi file, err: %s", err.Error())
+	}
+	str, err := newStorage(c)
+	if err != nil {
+		return err
+	}
+	for contract, contractABI := range abis {
+		if !ethereum.IsHexAddress(contract) {
+			return fmt.Errorf("contract is not a valid ethereum address, contract=%s", contract)
+		}
+		if err := str.StoreContractABI(ethereum.HexToAddress(contract), contractABI, common.ABISourceUser, cliActor()); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("imported %d abis\n", len(abis))
+	return nil
+}
+
+func abiVersionsCmd(c *cli.Context) error {
+	contract, err := contractArg(c)
+	if err != nil {
+		return err
+	}
+	str, err := newStorage(c)
+	if err != nil {
+		return err
+	}
+	versions, err := str.GetABIVersions(contract.Hex())
+	if err != nil {
+		return err
+	}
+	t := render.Table{Header: []string{"id", "source", "author", "created at"}}
+	for _, v := range versions {
+		t.Rows = append(t.Rows, []string{strconv.FormatInt(v.ID, 10), v.Source, v.Author,
+			time.Unix(v.CreatedAt, 0).UTC().Format(time.RFC3339)})
+	}
+	return render.Write(os.Stdout, c.String(formatFlag), t, versions)
+}
+
+func abiRollbackCmd(c *cli.Context) error {
+	contract, err := contractArg(c)
+	if err != nil {
+		return err
+	}
+	str, err := newStorage(c)
+	if err != nil {
+		return err
+	}
+	v, err := str.GetABIVersion(contract.Hex(), c.Int64(versionFlag))
+	if err != nil {
+		return err
+	}
+	if v == nil {
+		return cli.NewExitError(fmt.Sprintf("no abi version %d of contract %s", c.Int64(versionFlag), contract.Hex()), 1)
+	}
+	if err := str.StoreContractABI(contract, v.ABI, common.ABISourceRollback, cliActor()); err != nil {
+		return err
+	}
+	fmt.Printf("stored abi version %d of %s again\n", v.ID, contract.Hex())
+	return nil
+}
+
+func abiDiffCmd(c *cli.Context) error {
+	contract, err := contractArg(c)
+	if err != nil {
+		return err
+	}
+	coreInstance, err := newCore(c)
+	if err != nil {
+		return err
+	}
+	diff, err := coreInstance.DiffABIVersions(contract, c.Int64(fromFlag), c.Int64(toFlag))
+	if err != nil {
+		return err
+	}
+	t := render.Table{Header: []string{"entry", "kind", "name", "signature", "previous"}}
+	for _, entries := range []struct {
+		name    string
+		changes []common.ABIChange
+	}{{"function", diff.Functions}, {"event", diff.Events}, {"error", diff.Errors}} {
+		for _, ch := range entries.changes {
+			t.Rows = append(t.Rows, []string{entries.name, ch.Kind, ch.Name, ch.Signature, ch.Previous})
+		}
+	}
+	return render.Write(os.Stdout, c.String(formatFlag), t, diff)
+}
+
+func abiAuditCmd(c *cli.Context) error {
+	contract := c.String(contractFlag)
+	if contract != "" {
+		if !ethereum.IsHexAddress(contract) {
+			return fmt.Errorf("contract is not a valid ethereum address, contract=%s", contract)
+		}
+		contract = ethereum.HexToAddress(contract).Hex()
+	}
+	str, err := newStorage(c)
+	if err != nil {
+		return err
+	}
+	log, err := str.GetABIAuditLog(contract, c.Int(limitFlag))
+	if err != nil {
+		return err
+	}
+	t := render.Table{Header: []string{"id", "contract", "actor", "replaced", "created at"}}
+	for _, a := range log {
+		t.Rows = append(t.Rows, []string{strconv.FormatInt(a.ID, 10), a.Contract, a.Actor,
+			strconv.FormatBool(a.PreviousABI != ""), time.Unix(a.CreatedAt, 0).UTC().Format(time.RFC3339)})
+	}
+	return render.Write(os.Stdout, c.String(formatFlag), t, log)
+}
+
+func decodeCmd(c *cli.Context) error {
+	contractABI, err := readABIFile(c)
+	if err != nil {
+		return err
+	}
+	if contractABI == "" {
+		contract, err := contractArg(c)
+		if err != nil {
+			return err
+		}
+		str, err := newStorage(c)
+		if err != nil {
+			return err
+		}
+		if contractABI, err = str.GetContractABI(contract); err != nil {
+			return err
+		}
+		if contractABI == "" {
+			return fmt.Errorf("no abi stored for contract %s, use %s", contract.Hex(), abiFileFlag)
+		}
+	}
+	decoded, err := core.DecodeData(contractABI, c.String(methodFlag), c.String(dataFlag))
+	if err != nil {
+		return err
+	}
+	t := render.Table{Header: []string{"name", "type", "value"}}
+	for _, a := range decoded.Arguments {
+		t.Rows = append(t.Rows, []string{a.Name, a.Type, render.FormatValue(a.Value)})
+	}
+	return render.Write(os.Stdout, c.String(formatFlag), t, decoded)
+}
+
+func runBatchCmd(c *cli.Context) error {
+	data, err := ioutil.ReadFile(c.String(fileFlag))
+	if err != nil {
+		return err
+	}
+	b, err := core.ParseBatch(data)
+	if err != nil {
+		return err
+	}
+	coreInstance, err := newCore(c)
+	if err != nil {
+		return err
+	}
+	results, err := coreInstance.RunBatch(b)
+	if err != nil {
+		return err
+	}
+	out := os.Stdout
+	if path := c.String(outFlag); path != "" {
+		if out, err = os.Create(path); err != nil {
+			return err
+		}
+		defer func() {
+			_ = out.Close()
+		}()
+	}
+	if err := render.Write(out, c.String(formatFlag), render.BatchTable(results), results); err != nil {
+		return err
+	}
+	failed := 0
+	for _, r := range results {
+		if r.Err != "" {
+			failed++
+		}
+	}
+	if failed != 0 {
+		return cli.NewExitError(fmt.Sprintf("%d of %d calls failed", failed, len(results)), 1)
+	}
+	return nil
+}
+
+func interfacesCmd(c *cli.Context) error {
+	contract, err := contractArg(c)
+	if err != nil {
+		return err
+	}
+	coreInstance, err := newCore(c)
+	if err != nil {
+		return err
+	}
+	matches, err := coreInstance.DetectInterfaces(contract, c.String(blockFlag), c.String(customNodeFlag))
+	if err != nil {
+		return err
+	}
+	t := render.Table{Header: []string{"interface", "detection", "selectors"}}
+	for _, m := range matches {
+		t.Rows = append(t.Rows, []string{m.Name, m.Detection,
+			fmt.Sprintf("%d/%d", m.MatchedSelectors, m.TotalSelectors)})
+	}
+	return render.Write(os.Stdout, c.String(formatFlag), t, matches)
+}
+
+func balancesCmd(c *cli.Context) error {
+	coreInstance, err := newCore(c)
+	if err != nil {
+		return err
+	}
+	matrix, err := coreInstance.BalanceMatrix(c.StringSlice(holderFlag), c.StringSlice(tokenFlag),
+		c.StringSlice(spenderFlag), c.String(blockFlag), c.String(customNodeFlag))
+	if err != nil {
+		return err
+	}
+	return render.Write(os.Stdout, c.String(formatFlag), render.BalanceTable(matrix), matrix)
+}
@@ -0,0 +1,374 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+
+	ethereum "github.com/ethereum/go-ethereum/common"
+	"github.com/peterh/liner"
+	"github.com/urfave/cli"
+
+	"github.com/KyberNetwork/contract-caller/common"
+	"github.com/KyberNetwork/contract-caller/core"
+	"github.com/KyberNetwork/contract-caller/lib/render"
+)
+
+// consoleHistoryLimit is number of history lines loaded when console starts
+const consoleHistoryLimit = 1000
+
+const consoleHelp = `commands:
+  load <address> [abi file]   load a contract, abi is looked up in db and etherscan if no file is given
+  methods                     list view methods with argument types
+  <method> [args...]          call a method with positional arguments, e.g. balanceOf 0x...
+  <var> = <method> [args...]  call a method and keep its result as $<var>
+  block <number|latest>       switch block used for calls
+  node <url|default>          switch node used for calls
+  network <name>              switch network used for etherscan abi lookup
+  vars                        list variables, $<var> or $<var>.<index> can be used as argument
+  help                        show this help
+  exit                        leave the console
+results are kept as $1, $2, ... in call order`
+
+// console is state of an interactive console session
+type console struct {
+	core       *core.Core
+	out        io.Writer
+	contract   ethereum.Address
+	abi        string
+	methods    map[string]common.Method
+	block      string
+	customNode string
+	network    string
+	vars       map[string][]interface{}
+	calls      int
+}
+
+func consoleCmd(c *cli.Context) error {
+	coreInstance, err := newCore(c)
+	if err != nil {
+		return err
+	}
+	str, err := newStorage(c)
+	if err != nil {
+		return err
+	}
+	con := &console{
+		core:    coreInstance,
+		out:     c.App.Writer,
+		network: c.String(networkFlag),
+		vars:    make(map[string][]interface{}),
+	}
+	if contract := c.String(contractFlag); contract != "" {
+		args := []string{contract}
+		if abiFile := c.String(abiFileFlag); abiFile != "" {
+			args = append(args, abiFile)
+		}
+		if err := con.load(args); err != nil {
+			fmt.Fprintln(con.out, "error:", err)
+		}
+	}
+
+	line := liner.NewLiner()
+	defer func() {
+		_ = line.Close()
+	}()
+	line.SetCtrlCAborts(true)
+	line.SetCompleter(con.complete)
+	history, err := str.GetConsoleHistory(consoleHistoryLimit)
+	if err != nil {
+		return err
+	}
+	for _, h := range history {
+		line.AppendHistory(h)
+	}
+	fmt.Fprintln(con.out, "type help for available commands")
+	for {
+		input, err := line.Prompt(con.prompt())
+		if err == liner.ErrPromptAborted || err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		input = strings.TrimSpace(input)
+		if input == "" {
+			continue
+		}
+		line.AppendHistory(input)
+		if err := str.AddConsoleHistory(input); err != nil {
+			fmt.Fprintln(con.out, "error: cannot store history,", err)
+		}
+		if input == "exit" || input == "quit" {
+			return nil
+		}
+		if err := con.execute(input); err != nil {
+			fmt.Fprintln(con.out, "error:", err)
+		}
+	}
+}
+
+func (con *console) prompt() string {
+	if len(con.methods) == 0 {
+		return "> "
+	}
+	block := con.block
+	if block == "" {
+		block = "latest"
+	}
+	return fmt.Sprintf("%s@%s> ", con.contract.Hex()[:10], block)
+}
+
+// complete completes console commands and method names of the loaded contract
+func (con *console) complete(line string) []string {
+	if strings.Contains(line, " ") {
+		return nil
+	}
+	var candidates []string
+	for _, cmd := range []string{"load", "methods", "block", "node", "network", "vars", "help", "exit"} {
+		if strings.HasPrefix(cmd, line) {
+			candidates = append(candidates, cmd+" ")
+		}
+	}
+	for name := range con.methods {
+		if strings.HasPrefix(name, line) {
+			candidates = append(candidates, name+" ")
+		}
+	}
+	sort.Strings(candidates)
+	return candidates
+}
+
+func (con *console) execute(input string) error {
+	args, err := splitArgs(input)
+	if err != nil {
+		return err
+	}
+	switch args[0] {
+	case "help":
+		fmt.Fprintln(con.out, consoleHelp)
+		return nil
+	case "load":
+		return con.load(args[1:])
+	case "methods":
+		return con.listMethods()
+	case "block":
+		return con.setBlock(args[1:])
+	case "node":
+		return con.setNode(args[1:])
+	case "network":
+		if len(args) < 2 {
+			fmt.Fprintln(con.out, con.network)
+			return nil
+		}
+		con.network = strings.Join(args[1:], " ")
+		return nil
+	case "vars":
+		return con.listVars()
+	}
+	name := ""
+	if len(args) > 2 && args[1] == "=" {
+		name, args = strings.TrimPrefix(args[0], "$"), args[2:]
+	}
+	return con.call(name, args[0], args[1:])
+}
+
+func (con *console) load(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: load <address> [abi file]")
+	}
+	if !ethereum.IsHexAddress(args[0]) {
+		return fmt.Errorf("contract is not a valid ethereum address, contract=%s", args[0])
+	}
+	contract := ethereum.HexToAddress(args[0])
+	var (
+		contractABI string
+		err         error
+	)
+	if len(args) > 1 {
+		data, err := ioutil.ReadFile(args[1])
+		if err != nil {
+			return err
+		}
+		contractABI = string(data)
+	} else if contractABI, err = con.core.ContractABI(contract, con.network); err != nil {
+		return err
+	}
+	methods, err := con.core.ContractMethods(contract, contractABI, "", con.network)
+	if err != nil {
+		return err
+	}
+	con.contract, con.abi = contract, contractABI
+	con.methods = make(map[string]common.Method, len(methods))
+	for _, m := range methods {
+		con.methods[m.Name] = m
+	}
+	fmt.Fprintf(con.out, "loaded %s with %d view methods\n", contract.Hex(), len(methods))
+	return nil
+}
+
+func methodSignature(m common.Method) string {
+	var args []string
+	for _, a := range m.Arguments {
+		args = append(args, strings.TrimSpace(a.Type+" "+a.Name))
+	}
+	return fmt.Sprintf("%s(%s)", m.Name, strings.Join(args, ", "))
+}
+
+func (con *console) listMethods() error {
+	if len(con.methods) == 0 {
+		return fmt.Errorf("no contract loaded, use load <address>")
+	}
+	var signatures []string
+	for _, m := range con.methods {
+		signatures = append(signatures, methodSignature(m))
+	}
+	sort.Strings(signatures)
+	for _, s := range signatures {
+		fmt.Fprintln(con.out, s)
+	}
+	return nil
+}
+
+func (con *console) setBlock(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: block <number|latest>")
+	}
+	if args[0] == "latest" {
+		con.block = ""
+		return nil
+	}
+	if _, err := core.ParseBlockNumber(args[0]); err != nil {
+		return err
+	}
+	con.block = args[0]
+	return nil
+}
+
+func (con *console) setNode(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: node <url|default>")
+	}
+	node := args[0]
+	if node == "default" {
+		node = ""
+	}
+	network, err := con.core.NetworkInfo(node)
+	if err != nil {
+		return err
+	}
+	con.customNode = node
+	fmt.Fprintln(con.out, "network:", network)
+	return nil
+}
+
+func (con *console) listVars() error {
+	var names []string
+	for name := range con.vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		var values []string
+		for _, v := range con.vars[name] {
+			values = append(values, render.FormatValue(v))
+		}
+		fmt.Fprintf(con.out, "$%s = %s\n", name, strings.Join(values, " | "))
+	}
+	return nil
+}
+
+func (con *console) call(name, methodName string, args []string) error {
+	if len(con.methods) == 0 {
+		return fmt.Errorf("no contract loaded, use load <address>")
+	}
+	method, ok := con.methods[methodName]
+	if !ok {
+		return fmt.Errorf("unknown command or method %s, type help for available commands", methodName)
+	}
+	if len(args) != len(method.Arguments) {
+		return fmt.Errorf("usage: %s", methodSignature(method))
+	}
+	params := make(map[string]interface{}, len(args))
+	for i, a := range args {
+		value, err := con.resolveArg(a)
+		if err != nil {
+			return err
+		}
+		params[method.Arguments[i].Name] = value
+	}
+	result, err := con.core.CallContract(con.contract, con.abi, methodName, con.block, params, con.customNode)
+	if err != nil {
+		return err
+	}
+	outputs, _ := result.([]interface{})
+	con.calls++
+	con.vars[strconv.Itoa(con.calls)] = outputs
+	if name != "" {
+		con.vars[name] = outputs
+	}
+	for i, o := range outputs {
+		fmt.Fprintf(con.out, "[%d]: %s\n", i, render.FormatValue(o))
+	}
+	fmt.Fprintf(con.out, "saved as $%d\n", con.calls)
+	return nil
+}
+
+// resolveArg replaces $var (first output) or $var.index with the variable value as a call param
+func (con *console) resolveArg(arg string) (string, error) {
+	if !strings.HasPrefix(arg, "$") {
+		return arg, nil
+	}
+	parts := strings.SplitN(arg[1:], ".", 2)
+	outputs, ok := con.vars[parts[0]]
+	if !ok {
+		return "", fmt.Errorf("unknown variable %s", arg)
+	}
+	index := 0
+	if len(parts) == 2 {
+		var err error
+		if index, err = strconv.Atoi(parts[1]); err != nil {
+			return "", fmt.Errorf("output index must be a number, variable=%s", arg)
+		}
+	}
+	if index < 0 || index >= len(outputs) {
+		return "", fmt.Errorf("variable %s has no output %d", parts[0], index)
+	}
+	return render.FormatParam(outputs[index]), nil
+}
+
+// splitArgs splits input by spaces, keeping double quoted parts together
+func splitArgs(input string) ([]string, error) {
+	var (
+		args    []string
+		current strings.Builder
+		quoted  bool
+		started bool
+	)
+	for _, r := range input {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			started = true
+		case r == ' ' && !quoted:
+			if started {
+				args = append(args, current.String())
+				current.Reset()
+				started = false
+			}
+		default:
+			current.WriteRune(r)
+			started = true
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+	if started {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
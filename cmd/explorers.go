@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli"
+	"gopkg.in/yaml.v2"
+
+	"github.com/KyberNetwork/contract-caller/common"
+	"github.com/KyberNetwork/contract-caller/lib/etherscan"
+)
+
+// explorerConfig is an entry of explorers file, name registers a network the app does not know
+type explorerConfig struct {
+	etherscan.Config `yaml:",inline"`
+	Name             string `yaml:"name"`
+	NativeSymbol     string `yaml:"nativeSymbol"`
+}
+
+// readExplorers reads explorers file, then sets api keys given as chainID=key
+func readExplorers(path string, apiKeys []string) ([]etherscan.Config, error) {
+	var entries []explorerConfig
+	if path != "" {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := yaml.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("cannot read explorers file, err=%s", err)
+		}
+	}
+	configs := make(map[int64]etherscan.Config)
+	var chainIDs []int64
+	for _, e := range entries {
+		if e.ChainID == 0 {
+			return nil, fmt.Errorf("chainId is required in explorers file")
+		}
+		switch e.Flavor {
+		case "", common.ExplorerEtherscan, common.ExplorerBlockscout:
+		default:
+			return nil, fmt.Errorf("unknown explorer flavor, flavor=%s", e.Flavor)
+		}
+		if _, ok := common.NetworkByChainID(e.ChainID); !ok || e.Name != "" {
+			if e.Name == "" || e.BaseURL == "" {
+				return nil, fmt.Errorf("name and baseUrl are required for unknown chain %d", e.ChainID)
+			}
+			flavor := e.Flavor
+			if flavor == "" {
+				flavor = common.ExplorerEtherscan
+			}
+			common.RegisterNetwork(common.Network{
+				Name:           e.Name,
+				ChainID:        e.ChainID,
+				NativeSymbol:   e.NativeSymbol,
+				ExplorerAPI:    e.BaseURL,
+				ExplorerFlavor: flavor,
+			})
+		}
+		if _, ok := configs[e.ChainID]; !ok {
+			chainIDs = append(chainIDs, e.ChainID)
+		}
+		configs[e.ChainID] = e.Config
+	}
+	for _, k := range apiKeys {
+		parts := strings.SplitN(k, "=", 2)
+		chainID, err := strconv.ParseInt(parts[0], 10, 64)
+		if len(parts) != 2 || err != nil {
+			return nil, fmt.Errorf("explorer api key must be chainID=key, key=%s", k)
+		}
+		cfg, ok := configs[chainID]
+		if !ok {
+			chainIDs = append(chainIDs, chainID)
+		}
+		cfg.ChainID, cfg.APIKey = chainID, parts[1]
+		configs[chainID] = cfg
+	}
+	result := make([]etherscan.Config, 0, len(chainIDs))
+	for _, id := range chainIDs {
+		result = append(result, configs[id])
+	}
+	return result, nil
+}
+
+func newEtherscan(c *cli.Context) (*etherscan.Etherscan, error) {
+	configs, err := readExplorers(c.GlobalString(explorersFileFlag), c.GlobalStringSlice(explorerAPIKeyFlag))
+	if err != nil {
+		return nil, err
+	}
+	return etherscan.NewEtherscan(c.GlobalString(etherscanAPIKeyFlag), configs...), nil
+}
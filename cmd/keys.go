@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/KyberNetwork/contract-caller/common"
+	"github.com/KyberNetwork/contract-caller/core"
+	"github.com/KyberNetwork/contract-caller/lib/render"
+)
+
+var (
+	nameFlag       = "name"
+	rateLimitFlag  = "rate-limit"
+	dailyQuotaFlag = "daily-quota"
+	idFlag         = "id"
+	roleFlag       = "role"
+)
+
+func keysCommand() cli.Command {
+	return cli.Command{
+		Name:  "keys",
+		Usage: "manage api keys of the server",
+		Subcommands: []cli.Command{
+			{
+				Name:   "create",
+				Usage:  "create an api key, the key is printed once and only its hash is stored",
+				Action: keysCreateCmd,
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  nameFlag,
+						Usage: "name of the key owner",
+					},
+					cli.StringFlag{
+						Name:  roleFlag,
+						Usage: "role of the key: viewer, editor (can store abis) or admin (can use any custom node)",
+						Value: common.RoleViewer,
+					},
+					cli.Float64Flag{
+						Name:  rateLimitFlag,
+						Usage: "requests per second, 0 is unlimited",
+					},
+					cli.Int64Flag{
+						Name:  dailyQuotaFlag,
+						Usage: "requests per UTC day, 0 is unlimited",
+					},
+				},
+			},
+			{
+				Name:   "list",
+				Usage:  "list api keys",
+				Action: keysListCmd,
+				Flags:  []cli.Flag{formatCliFlag},
+			},
+			{
+				Name:   "revoke",
+				Usage:  "revoke an api key",
+				Action: keysRevokeCmd,
+				Flags: []cli.Flag{cli.Int64Flag{
+					Name:  idFlag,
+					Usage: "id of the key",
+				}},
+			},
+		},
+	}
+}
+
+func keysCreateCmd(c *cli.Context) error {
+	secret, k, err := core.NewAPIKey(c.String(nameFlag), c.String(roleFlag), c.Float64(rateLimitFlag), c.Int64(dailyQuotaFlag))
+	if err != nil {
+		return err
+	}
+	str, err := newStorage(c)
+	if err != nil {
+		return err
+	}
+	id, err := str.StoreAPIKey(k, core.HashAPIKey(secret))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("created %s api key %d for %s, it is not shown again:\n%s\n", k.Role, id, k.Name, secret)
+	return nil
+}
+
+func keysListCmd(c *cli.Context) error {
+	str, err := newStorage(c)
+	if err != nil {
+		return err
+	}
+	keys, err := str.GetAPIKeys()
+	if err != nil {
+		return err
+	}
+	t := render.Table{Header: []string{"id", "name", "prefix", "role", "rate limit", "daily quota", "revoked", "created at"}}
+	for _, k := range keys {
+		t.Rows = append(t.Rows, []string{strconv.FormatInt(k.ID, 10), k.Name, k.Prefix, k.Role,
+			strconv.FormatFloat(k.RateLimit, 'f', -1, 64), strconv.FormatInt(k.DailyQuota, 10),
+			strconv.FormatBool(k.Revoked), time.Unix(k.CreatedAt, 0).UTC().Format(time.RFC3339)})
+	}
+	return render.Write(os.Stdout, c.String(formatFlag), t, keys)
+}
+
+func keysRevokeCmd(c *cli.Context) error {
+	str, err := newStorage(c)
+	if err != nil {
+		return err
+	}
+	ok, err := str.RevokeAPIKey(c.Int64(idFlag))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return cli.NewExitError(fmt.Sprintf("no api key with id %d", c.Int64(idFlag)), 1)
+	}
+	fmt.Printf("revoked api key %d\n", c.Int64(idFlag))
+	return nil
+}
@@ -1,14 +1,17 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"os"
+	"time"
 
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/urfave/cli"
 	"go.uber.org/zap"
 
+	"github.com/KyberNetwork/contract-caller/common"
 	"github.com/KyberNetwork/contract-caller/core"
-	"github.com/KyberNetwork/contract-caller/lib/etherscan"
 	"github.com/KyberNetwork/contract-caller/server"
 	"github.com/KyberNetwork/contract-caller/storage"
 )
@@ -16,14 +19,26 @@ import (
 var (
 	sugar = zap.S()
 
-	hostHTTPFlag        = "host"
-	defaultHost         = "localhost:3001"
-	etherscanAPIKeyFlag = "etherscan-apikey"
-	nodeFlag            = "node"
-	dbPathFlag          = "db-path"
-	defaultDBPath       = "contract.db"
-	staticPathFlag      = "static-path"
-	defaultStaticPath   = "../html/app/build"
+	hostHTTPFlag         = "host"
+	defaultHost          = "localhost:3001"
+	etherscanAPIKeyFlag  = "etherscan-apikey"
+	explorerAPIKeyFlag   = "explorer-apikey"
+	explorersFileFlag    = "explorers-file"
+	nodeFlag             = "node"
+	dbPathFlag           = "db-path"
+	defaultDBPath        = "contract.db"
+	staticPathFlag       = "static-path"
+	defaultStaticPath    = "../html/app/build"
+	alertIntervalFlag    = "alert-interval"
+	defaultAlertInterval = time.Minute
+	requireAuthFlag      = "require-auth"
+	corsOriginFlag       = "cors-origin"
+	anonymousRoleFlag    = "anonymous-role"
+	nodeAllowlistFlag    = "custom-node-allowlist"
+	nodeSchemeFlag       = "node-scheme"
+	nodeAllowHostFlag    = "node-allow-host"
+	nodeDenyHostFlag     = "node-deny-host"
+	nodeAllowPrivateFlag = "node-allow-private"
 )
 
 func main() {
@@ -32,6 +47,7 @@ func main() {
 	app.Version = "0.0.1"
 	app.Usage = "easy interface to call contract"
 	app.Action = run
+	app.Commands = commands()
 
 	app.Flags = append(app.Flags, cli.StringFlag{
 		Name:   hostHTTPFlag,
@@ -40,8 +56,16 @@ func main() {
 		Value:  defaultHost,
 	}, cli.StringFlag{
 		Name:   etherscanAPIKeyFlag,
-		Usage:  "etherscan API key",
+		Usage:  "etherscan API key, used for etherscan.io networks without their own key",
 		EnvVar: "ETHERSCAN_APIKEY",
+	}, cli.StringSliceFlag{
+		Name:   explorerAPIKeyFlag,
+		Usage:  "explorer API key of a chain as chainID=key (e.g. 56=<bscscan key>), can be repeated",
+		EnvVar: "EXPLORER_APIKEYS",
+	}, cli.StringFlag{
+		Name:   explorersFileFlag,
+		Usage:  "yaml file of explorers per chain: chainId, apiKey, baseUrl, flavor (etherscan or blockscout), name",
+		EnvVar: "EXPLORERS_FILE",
 	}, cli.StringFlag{
 		Name:   nodeFlag,
 		Usage:  "ethereum node",
@@ -56,6 +80,44 @@ func main() {
 		Usage:  "static data",
 		Value:  defaultStaticPath,
 		EnvVar: "STATIC_PATH",
+	}, cli.DurationFlag{
+		Name:   alertIntervalFlag,
+		Usage:  "interval between alert evaluations",
+		Value:  defaultAlertInterval,
+		EnvVar: "ALERT_INTERVAL",
+	}, cli.BoolFlag{
+		Name:   requireAuthFlag,
+		Usage:  "reject requests without a valid api key, keys are managed with the keys command",
+		EnvVar: "REQUIRE_AUTH",
+	}, cli.StringSliceFlag{
+		Name:   corsOriginFlag,
+		Usage:  "origin allowed to call the api (e.g. https://app.example.com), can be repeated, all origins if empty",
+		EnvVar: "CORS_ORIGINS",
+	}, cli.StringFlag{
+		Name:   anonymousRoleFlag,
+		Usage:  "role of requests without api key: viewer, editor or admin",
+		Value:  common.RoleViewer,
+		EnvVar: "ANONYMOUS_ROLE",
+	}, cli.StringSliceFlag{
+		Name:   nodeAllowlistFlag,
+		Usage:  "custom node (url or host) any role can use, can be repeated, other custom nodes need admin role",
+		EnvVar: "CUSTOM_NODE_ALLOWLIST",
+	}, cli.StringSliceFlag{
+		Name:   nodeSchemeFlag,
+		Usage:  "url scheme allowed for custom nodes, can be repeated, http, https, ws and wss if empty",
+		EnvVar: "NODE_SCHEMES",
+	}, cli.StringSliceFlag{
+		Name:   nodeAllowHostFlag,
+		Usage:  "only host allowed for custom nodes (name, .suffix, ip or cidr), can be repeated, may be private",
+		EnvVar: "NODE_ALLOW_HOSTS",
+	}, cli.StringSliceFlag{
+		Name:   nodeDenyHostFlag,
+		Usage:  "host denied for custom nodes (name, .suffix, ip or cidr), can be repeated",
+		EnvVar: "NODE_DENY_HOSTS",
+	}, cli.BoolFlag{
+		Name:   nodeAllowPrivateFlag,
+		Usage:  "allow custom nodes on loopback, private and link-local addresses",
+		EnvVar: "NODE_ALLOW_PRIVATE",
 	},
 	)
 
@@ -70,11 +132,19 @@ func main() {
 	}()
 	if err := app.Run(os.Args); err != nil {
 		sugar.Errorw("app error", "err", err)
+		_ = sugar.Sync()
+		os.Exit(1)
 	}
 }
 
 func run(c *cli.Context) error {
-	esc := etherscan.NewEtherscan(c.String(etherscanAPIKeyFlag))
+	if !core.ValidRole(c.String(anonymousRoleFlag)) {
+		return fmt.Errorf("unknown %s, role=%s", anonymousRoleFlag, c.String(anonymousRoleFlag))
+	}
+	esc, err := newEtherscan(c)
+	if err != nil {
+		return err
+	}
 	ecli, err := ethclient.Dial(c.String(nodeFlag))
 	if err != nil {
 		return err
@@ -87,6 +157,20 @@ func run(c *cli.Context) error {
 	if err != nil {
 		return err
 	}
-	s := server.NewServer(c.String(hostHTTPFlag), coreInstance)
+	if err := coreInstance.SetNodePolicy(core.NodePolicy{
+		Schemes:      c.StringSlice(nodeSchemeFlag),
+		AllowHosts:   c.StringSlice(nodeAllowHostFlag),
+		DenyHosts:    c.StringSlice(nodeDenyHostFlag),
+		AllowPrivate: c.Bool(nodeAllowPrivateFlag),
+	}); err != nil {
+		return err
+	}
+	go coreInstance.RunAlerts(context.Background(), c.Duration(alertIntervalFlag))
+	s := server.NewServer(c.String(hostHTTPFlag), coreInstance, server.Config{
+		RequireAuth:         c.Bool(requireAuthFlag),
+		CORSOrigins:         c.StringSlice(corsOriginFlag),
+		AnonymousRole:       c.String(anonymousRoleFlag),
+		CustomNodeAllowlist: c.StringSlice(nodeAllowlistFlag),
+	})
 	return s.Run(c.String(staticPathFlag))
 }
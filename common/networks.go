@@ -7,10 +7,84 @@ const (
 	EthereumRopsten string = "Ethereum Testnet - Ropsten"
 	// EthereumKovan ...
 	EthereumKovan string = "Ethereum Testnet - Kovan"
+	// EthereumGoerli ...
+	EthereumGoerli string = "Ethereum Testnet - Goerli"
 	// BSCMainnet ...
 	BSCMainnet string = "Binance Smart Chain Mainnet"
 	// BSCTestnet ...
 	BSCTestnet string = "Binance Smart Chain Testnet"
+	// PolygonMainnet ...
+	PolygonMainnet string = "Polygon Mainnet"
+	// ArbitrumMainnet ...
+	ArbitrumMainnet string = "Arbitrum One"
+	// OptimismMainnet ...
+	OptimismMainnet string = "Optimism Mainnet"
 	// UnknowNetwork ...
 	UnknowNetwork string = "Unknow Network"
 )
+
+const (
+	// ExplorerEtherscan is flavor of Etherscan compatible explorer apis (Etherscan, BscScan, Polygonscan, ...)
+	ExplorerEtherscan = "etherscan"
+	// ExplorerBlockscout is flavor of Blockscout explorer apis
+	ExplorerBlockscout = "blockscout"
+)
+
+// Network is a chain known by the app and its default explorer api
+type Network struct {
+	Name           string `json:"name"`
+	ChainID        int64  `json:"chainId"`
+	NativeSymbol   string `json:"nativeSymbol"`
+	ExplorerAPI    string `json:"explorerApi"`
+	ExplorerFlavor string `json:"explorerFlavor"`
+}
+
+// networks is the network registry
+var networks = []Network{
+	{EthereumMainnet, 1, "ETH", "https://api.etherscan.io", ExplorerEtherscan},
+	{EthereumRopsten, 3, "ETH", "https://api-ropsten.etherscan.io", ExplorerEtherscan},
+	{EthereumGoerli, 5, "ETH", "https://api-goerli.etherscan.io", ExplorerEtherscan},
+	{EthereumKovan, 42, "ETH", "https://api-kovan.etherscan.io", ExplorerEtherscan},
+	{BSCMainnet, 56, "BNB", "https://api.bscscan.com", ExplorerEtherscan},
+	{BSCTestnet, 97, "BNB", "https://api-testnet.bscscan.com", ExplorerEtherscan},
+	{PolygonMainnet, 137, "MATIC", "https://api.polygonscan.com", ExplorerEtherscan},
+	{ArbitrumMainnet, 42161, "ETH", "https://api.arbiscan.io", ExplorerEtherscan},
+	{OptimismMainnet, 10, "ETH", "https://api-optimistic.etherscan.io", ExplorerEtherscan},
+}
+
+// Networks returns all networks of the registry
+func Networks() []Network {
+	return append([]Network{}, networks...)
+}
+
+// NetworkByName returns network of the registry by name
+func NetworkByName(name string) (Network, bool) {
+	for _, n := range networks {
+		if n.Name == name {
+			return n, true
+		}
+	}
+	return Network{}, false
+}
+
+// NetworkByChainID returns network of the registry by chain id
+func NetworkByChainID(chainID int64) (Network, bool) {
+	for _, n := range networks {
+		if n.ChainID == chainID {
+			return n, true
+		}
+	}
+	return Network{}, false
+}
+
+// RegisterNetwork adds a network to the registry or replaces the one with the same chain id,
+// it is meant to be called on startup before networks are looked up
+func RegisterNetwork(network Network) {
+	for i, n := range networks {
+		if n.ChainID == network.ChainID {
+			networks[i] = network
+			return
+		}
+	}
+	networks = append(networks, network)
+}
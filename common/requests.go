@@ -0,0 +1,158 @@
+package common
+
+import "encoding/json"
+
+// MethodsRequest is body of /contract/methods, interface is used as abi of contracts without a verified one
+type MethodsRequest struct {
+	Contract    string `json:"contract" binding:"required"`
+	ABI         string `json:"abi"`
+	Interface   string `json:"interface"`
+	RememberABI bool   `json:"rememberABI"`
+	Network     string `json:"network"`
+}
+
+// CallContractRequest is body of /contract/call
+type CallContractRequest struct {
+	Contract    string                 `json:"contract" binding:"required"`
+	ABI         string                 `json:"abi"`
+	Interface   string                 `json:"interface"`
+	Method      string                 `json:"method" binding:"required"`
+	BlockNumber string                 `json:"blockNumber"`
+	Params      map[string]interface{} `json:"params"`
+	CustomNode  string                 `json:"customNode"`
+}
+
+// MulticallRequest is body of /contract/multicall
+type MulticallRequest struct {
+	Calls       []CallRequest `json:"calls" binding:"required"`
+	BlockNumber string        `json:"blockNumber"`
+	CustomNode  string        `json:"customNode"`
+}
+
+// DecodeRequest is params of decoding calldata, or return data of method when it is set. Abi is looked up
+// by contract when abi and interface are empty
+type DecodeRequest struct {
+	Contract  string `json:"contract"`
+	ABI       string `json:"abi"`
+	Interface string `json:"interface"`
+	Network   string `json:"network"`
+	Method    string `json:"method"`
+	Data      string `json:"data" binding:"required"`
+}
+
+// DiffRequest is body of /contract/diff
+type DiffRequest struct {
+	Contract   string `json:"contract" binding:"required"`
+	ABI        string `json:"abi"`
+	Network    string `json:"network"`
+	FromBlock  string `json:"fromBlock" binding:"required"`
+	ToBlock    string `json:"toBlock" binding:"required"`
+	CustomNode string `json:"customNode"`
+}
+
+// WatchQuery is query of /contract/watch, params is json encoded object
+type WatchQuery struct {
+	Contract   string `form:"contract" binding:"required"`
+	ABI        string `form:"abi"`
+	Network    string `form:"network"`
+	Method     string `form:"method" binding:"required"`
+	Params     string `form:"params"`
+	CustomNode string `form:"customNode"`
+}
+
+// PipelineRequest is body of /contract/pipeline
+type PipelineRequest struct {
+	Steps       []PipelineStep `json:"steps" binding:"required"`
+	BlockNumber string         `json:"blockNumber"`
+	Network     string         `json:"network"`
+	CustomNode  string         `json:"customNode"`
+}
+
+// StorageRequest is body of /contract/storage, layout can be given as json or as a json encoded string
+type StorageRequest struct {
+	Contract       string          `json:"contract" binding:"required"`
+	Slots          []string        `json:"slots"`
+	Variables      []string        `json:"variables"`
+	Layout         json.RawMessage `json:"layout"`
+	RememberLayout bool            `json:"rememberLayout"`
+	BlockNumber    string          `json:"blockNumber"`
+	CustomNode     string          `json:"customNode"`
+}
+
+// NodeQuery is query of endpoints reading a contract at a block
+type NodeQuery struct {
+	BlockNumber string `form:"blockNumber"`
+	CustomNode  string `form:"customNode"`
+}
+
+// TokenQuery is query of token endpoints
+type TokenQuery struct {
+	Owner       string `form:"owner"`
+	Spender     string `form:"spender"`
+	ID          string `form:"id"`
+	BlockNumber string `form:"blockNumber"`
+	CustomNode  string `form:"customNode"`
+}
+
+// BalancesRequest is body of /token/balances
+type BalancesRequest struct {
+	Holders     []string `json:"holders" binding:"required"`
+	Tokens      []string `json:"tokens" binding:"required"`
+	Spenders    []string `json:"spenders"`
+	BlockNumber string   `json:"blockNumber"`
+	CustomNode  string   `json:"customNode"`
+}
+
+// HistoryQuery is query of call history, from and to are unix timestamps in seconds
+type HistoryQuery struct {
+	Contract string `form:"contract"`
+	Method   string `form:"method"`
+	From     int64  `form:"from"`
+	To       int64  `form:"to"`
+	Limit    int    `form:"limit"`
+}
+
+// QuerySearch is query of saved query search
+type QuerySearch struct {
+	Tag  string `form:"tag"`
+	Text string `form:"q"`
+}
+
+// RunQueryQuery is query of running a saved query
+type RunQueryQuery struct {
+	CustomNode string `form:"customNode"`
+}
+
+// ReplayQuery is query of replaying a recorded call, at=latest replays at latest block instead of the original one
+type ReplayQuery struct {
+	At string `form:"at"`
+}
+
+// ABIAuditQuery is query of the abi audit log, empty contract means all contracts
+type ABIAuditQuery struct {
+	Contract string `form:"contract"`
+	Limit    int    `form:"limit"`
+}
+
+// LayoutAuditQuery is query of the storage layout audit log, empty contract means all contracts
+type LayoutAuditQuery struct {
+	Contract string `form:"contract"`
+	Limit    int    `form:"limit"`
+}
+
+// NetworkInfoQuery is query of /contract/network-info
+type NetworkInfoQuery struct {
+	Node string `form:"node"`
+}
+
+// ABIRollbackRequest is body of /abi/rollback/:address, version is id of the abi version to store again
+type ABIRollbackRequest struct {
+	Version int64 `json:"version" binding:"required"`
+}
+
+// ABIDiffQuery is query of /abi/diff/:address, to is the latest version and from is the version before to
+// when they are zero
+type ABIDiffQuery struct {
+	From int64 `form:"from"`
+	To   int64 `form:"to"`
+}
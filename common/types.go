@@ -1,9 +1,12 @@
 package common
 
+import "encoding/json"
+
 // Method ...
 type Method struct {
 	Name      string     `json:"name"`
 	Arguments []Argument `json:"arguments"`
+	Doc       string     `json:"doc,omitempty"`
 }
 
 // Argument ...
@@ -11,3 +14,408 @@ type Argument struct {
 	Name string `json:"name"`
 	Type string `json:"type"`
 }
+
+// StateDiff is value of a view method at two blocks
+type StateDiff struct {
+	Method  string      `json:"method"`
+	From    interface{} `json:"from"`
+	To      interface{} `json:"to"`
+	FromErr string      `json:"fromErr,omitempty"`
+	ToErr   string      `json:"toErr,omitempty"`
+	Changed bool        `json:"changed"`
+}
+
+// WatchUpdate is a new value of a watched call
+type WatchUpdate struct {
+	BlockNumber uint64      `json:"blockNumber"`
+	Result      interface{} `json:"result,omitempty"`
+	Err         string      `json:"err,omitempty"`
+}
+
+const (
+	// AlertConditionDeviation triggers when value changes more than threshold percent from last value
+	AlertConditionDeviation = "deviation"
+	// AlertConditionEquals triggers when value equals threshold
+	AlertConditionEquals = "equals"
+	// AlertConditionGreaterThan triggers when value is greater than threshold
+	AlertConditionGreaterThan = "gt"
+	// AlertConditionLessThan triggers when value is less than threshold
+	AlertConditionLessThan = "lt"
+
+	// WebhookFormatJSON posts a generic json payload
+	WebhookFormatJSON = "json"
+	// WebhookFormatSlack posts a slack-compatible payload
+	WebhookFormatSlack = "slack"
+)
+
+// Alert is a rule evaluated periodically against first output of a call
+type Alert struct {
+	ID            int64                  `json:"id"`
+	Name          string                 `json:"name"`
+	Contract      string                 `json:"contract"`
+	Network       string                 `json:"network"`
+	Method        string                 `json:"method"`
+	Params        map[string]interface{} `json:"params"`
+	Condition     string                 `json:"condition"`
+	Threshold     string                 `json:"threshold"`
+	WebhookURL    string                 `json:"webhookURL"`
+	WebhookFormat string                 `json:"webhookFormat"`
+	LastValue     string                 `json:"lastValue"`
+	Triggered     bool                   `json:"triggered"`
+}
+
+// SavedQuery is a named call that can be re-run by its permalink id
+type SavedQuery struct {
+	ID          string                 `json:"id"`
+	Name        string                 `json:"name"`
+	Network     string                 `json:"network"`
+	Contract    string                 `json:"contract"`
+	Method      string                 `json:"method"`
+	Params      map[string]interface{} `json:"params"`
+	BlockNumber string                 `json:"blockNumber"`
+	Tags        []string               `json:"tags"`
+	CreatedAt   int64                  `json:"createdAt"`
+}
+
+// CallHistory is a recorded contract call
+type CallHistory struct {
+	ID            int64                  `json:"id"`
+	Contract      string                 `json:"contract"`
+	ABI           string                 `json:"-"`
+	Method        string                 `json:"method"`
+	Params        map[string]interface{} `json:"params"`
+	BlockNumber   string                 `json:"blockNumber"`
+	ResolvedBlock uint64                 `json:"resolvedBlock"`
+	Node          string                 `json:"node"`
+	Result        json.RawMessage        `json:"result,omitempty"`
+	Err           string                 `json:"err,omitempty"`
+	LatencyMs     int64                  `json:"latencyMs"`
+	CreatedAt     int64                  `json:"createdAt"`
+}
+
+// HistoryFilter filters call history, zero fields are ignored
+type HistoryFilter struct {
+	Contract string
+	Method   string
+	From     int64
+	To       int64
+	Limit    int
+}
+
+// Replay is result of re-executing a recorded call
+type Replay struct {
+	Original    CallHistory     `json:"original"`
+	BlockNumber uint64          `json:"blockNumber"`
+	Result      json.RawMessage `json:"result,omitempty"`
+	Err         string          `json:"err,omitempty"`
+	Changed     bool            `json:"changed"`
+}
+
+// CallRequest is a single call of a multicall
+type CallRequest struct {
+	Contract string                 `json:"contract"`
+	ABI      string                 `json:"abi"`
+	Method   string                 `json:"method"`
+	Params   map[string]interface{} `json:"params"`
+}
+
+// CallResult is result of a single call of a multicall
+type CallResult struct {
+	Result interface{} `json:"result,omitempty"`
+	Err    string      `json:"err,omitempty"`
+}
+
+// DecodedArgument is a decoded input or output of a method
+type DecodedArgument struct {
+	Name  string      `json:"name"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+// DecodedData is decoded calldata or return data of a method
+type DecodedData struct {
+	Method    string            `json:"method"`
+	Signature string            `json:"signature"`
+	Arguments []DecodedArgument `json:"arguments"`
+}
+
+// Batch is a declarative list of calls over networks and aliased contracts
+type Batch struct {
+	Networks  []BatchNetwork  `json:"networks" yaml:"networks"`
+	Contracts []BatchContract `json:"contracts" yaml:"contracts"`
+	Calls     []BatchCall     `json:"calls" yaml:"calls"`
+}
+
+// BatchNetwork is a node calls are sent to, empty node means the default node
+type BatchNetwork struct {
+	Name  string `json:"name" yaml:"name"`
+	Node  string `json:"node" yaml:"node"`
+	Block string `json:"block" yaml:"block"`
+}
+
+// BatchContract is a contract referred by its alias in batch calls
+type BatchContract struct {
+	Alias   string `json:"alias" yaml:"alias"`
+	Address string `json:"address" yaml:"address"`
+	Network string `json:"network" yaml:"network"`
+	ABI     string `json:"abi" yaml:"abi"`
+}
+
+// BatchCall is a labeled call of a batch contract
+type BatchCall struct {
+	Label    string                 `json:"label" yaml:"label"`
+	Contract string                 `json:"contract" yaml:"contract"`
+	Method   string                 `json:"method" yaml:"method"`
+	Params   map[string]interface{} `json:"params" yaml:"params"`
+}
+
+// BatchResult is result of a batch call
+type BatchResult struct {
+	Label    string      `json:"label"`
+	Network  string      `json:"network"`
+	Contract string      `json:"contract"`
+	Address  string      `json:"address"`
+	Method   string      `json:"method"`
+	Result   interface{} `json:"result,omitempty"`
+	Err      string      `json:"err,omitempty"`
+}
+
+// PipelineStep is a call whose contract and string params can reference outputs of earlier steps
+// as ${step.output}, ${step.index} or ${step} (first output)
+type PipelineStep struct {
+	Name     string                 `json:"name"`
+	Contract string                 `json:"contract"`
+	ABI      string                 `json:"abi"`
+	Method   string                 `json:"method"`
+	Params   map[string]interface{} `json:"params"`
+}
+
+// PipelineStepResult is result of a pipeline step, outputs are keyed by name (or index if unnamed)
+type PipelineStepResult struct {
+	Name     string                 `json:"name"`
+	Contract string                 `json:"contract"`
+	Method   string                 `json:"method"`
+	Params   map[string]interface{} `json:"params"`
+	Outputs  map[string]interface{} `json:"outputs"`
+}
+
+// PipelineResult is result of all steps of a pipeline executed at one block
+type PipelineResult struct {
+	BlockNumber uint64               `json:"blockNumber"`
+	Steps       []PipelineStepResult `json:"steps"`
+}
+
+const (
+	// TokenStandardERC20 ...
+	TokenStandardERC20 = "erc20"
+	// TokenStandardERC721 ...
+	TokenStandardERC721 = "erc721"
+	// TokenStandardERC1155 ...
+	TokenStandardERC1155 = "erc1155"
+	// TokenStandardUnknown ...
+	TokenStandardUnknown = "unknown"
+)
+
+// TokenInfo is metadata of a token contract, amounts are raw and formatted with decimals
+type TokenInfo struct {
+	Address              string `json:"address"`
+	Standard             string `json:"standard"`
+	Name                 string `json:"name,omitempty"`
+	Symbol               string `json:"symbol,omitempty"`
+	Decimals             uint8  `json:"decimals"`
+	TotalSupply          string `json:"totalSupply,omitempty"`
+	TotalSupplyFormatted string `json:"totalSupplyFormatted,omitempty"`
+}
+
+// TokenAmount is a balance or allowance of a token
+type TokenAmount struct {
+	Token          string `json:"token"`
+	Standard       string `json:"standard"`
+	Raw            string `json:"raw"`
+	Formatted      string `json:"formatted"`
+	Decimals       uint8  `json:"decimals"`
+	ApprovedForAll *bool  `json:"approvedForAll,omitempty"`
+}
+
+// NativeToken is the token name of the chain native coin (ETH, BNB) in balance requests
+const NativeToken = "native"
+
+// BalanceToken is a column of a balance matrix, totals are summed over all holders
+type BalanceToken struct {
+	Address        string `json:"address"`
+	Symbol         string `json:"symbol"`
+	Decimals       uint8  `json:"decimals"`
+	Total          string `json:"total"`
+	TotalFormatted string `json:"totalFormatted"`
+	Err            string `json:"err,omitempty"`
+}
+
+// BalanceCell is balance of a holder for a token
+type BalanceCell struct {
+	Raw       string `json:"raw"`
+	Formatted string `json:"formatted"`
+	Err       string `json:"err,omitempty"`
+}
+
+// AllowanceRow is allowances a holder gave a spender for tokens (columns), cells of native coin are empty
+type AllowanceRow struct {
+	Holder     string        `json:"holder"`
+	Spender    string        `json:"spender"`
+	Allowances []BalanceCell `json:"allowances"`
+}
+
+// BalanceMatrix is balances of holders (rows) for tokens (columns) at a block, and allowances of holders for
+// spenders
+type BalanceMatrix struct {
+	BlockNumber uint64          `json:"blockNumber"`
+	Holders     []string        `json:"holders"`
+	Spenders    []string        `json:"spenders,omitempty"`
+	Tokens      []BalanceToken  `json:"tokens"`
+	Balances    [][]BalanceCell `json:"balances"`
+	Allowances  []AllowanceRow  `json:"allowances,omitempty"`
+}
+
+const (
+	// DetectionERC165 ...
+	DetectionERC165 = "erc165"
+	// DetectionBytecode ...
+	DetectionBytecode = "bytecode"
+	// DetectionProbe ...
+	DetectionProbe = "probe"
+)
+
+// InterfaceMatch is a standard interface implemented by a contract and how it was detected
+type InterfaceMatch struct {
+	Name             string `json:"name"`
+	Detection        string `json:"detection"`
+	MatchedSelectors int    `json:"matchedSelectors"`
+	TotalSelectors   int    `json:"totalSelectors"`
+}
+
+// StorageSlot is raw value of a storage slot
+type StorageSlot struct {
+	Slot  string `json:"slot"`
+	Value string `json:"value"`
+}
+
+// StorageVariable is a state variable resolved with the compiler storage layout, length is set
+// for dynamic arrays read without an index
+type StorageVariable struct {
+	Variable string      `json:"variable"`
+	Type     string      `json:"type,omitempty"`
+	Slot     string      `json:"slot,omitempty"`
+	Offset   int         `json:"offset"`
+	Value    interface{} `json:"value,omitempty"`
+	Length   string      `json:"length,omitempty"`
+	Err      string      `json:"err,omitempty"`
+}
+
+// StorageResult is storage of a contract read at a block
+type StorageResult struct {
+	BlockNumber uint64            `json:"blockNumber"`
+	Slots       []StorageSlot     `json:"slots"`
+	Variables   []StorageVariable `json:"variables"`
+}
+
+// ContractSource is metadata of a verified contract from explorer, docs are NatSpec of functions by name
+type ContractSource struct {
+	Address          string            `json:"address"`
+	Name             string            `json:"name"`
+	CompilerVersion  string            `json:"compilerVersion"`
+	OptimizationUsed bool              `json:"optimizationUsed"`
+	Runs             int               `json:"runs"`
+	EVMVersion       string            `json:"evmVersion"`
+	License          string            `json:"license"`
+	Proxy            bool              `json:"proxy"`
+	Implementation   string            `json:"implementation,omitempty"`
+	Docs             map[string]string `json:"docs"`
+	ABI              string            `json:"-"`
+}
+
+const (
+	// RoleViewer can read contracts and use the default node and allowlisted custom nodes
+	RoleViewer = "viewer"
+	// RoleEditor can also store and replace abis
+	RoleEditor = "editor"
+	// RoleAdmin can also use any custom node and read the abi audit log
+	RoleAdmin = "admin"
+)
+
+// APIKey is a key clients authenticate with, only its hash is stored. Rate limit is requests per second and
+// daily quota is requests per UTC day, zero means unlimited
+type APIKey struct {
+	ID         int64   `json:"id"`
+	Name       string  `json:"name"`
+	Prefix     string  `json:"prefix"`
+	Role       string  `json:"role"`
+	RateLimit  float64 `json:"rateLimit"`
+	DailyQuota int64   `json:"dailyQuota"`
+	Revoked    bool    `json:"revoked"`
+	CreatedAt  int64   `json:"createdAt"`
+}
+
+// ABIAudit is a write of a stored abi, previous abi is empty when the contract had none
+type ABIAudit struct {
+	ID          int64  `json:"id"`
+	Contract    string `json:"contract"`
+	Actor       string `json:"actor"`
+	PreviousABI string `json:"previousAbi"`
+	ABI         string `json:"abi"`
+	CreatedAt   int64  `json:"createdAt"`
+}
+
+// LayoutAudit is a write of a contract storage layout, with who wrote it and the layout it replaced
+type LayoutAudit struct {
+	ID             int64  `json:"id"`
+	Contract       string `json:"contract"`
+	Actor          string `json:"actor"`
+	PreviousLayout string `json:"previousLayout"`
+	Layout         string `json:"layout"`
+	CreatedAt      int64  `json:"createdAt"`
+}
+
+const (
+	// ABISourceUser is an abi uploaded by a user
+	ABISourceUser = "user"
+	// ABISourceEtherscan is an abi of a contract verified on the explorer
+	ABISourceEtherscan = "etherscan"
+	// ABISourceArtifact is an abi read from a compiler artifact
+	ABISourceArtifact = "artifact"
+	// ABISourceRollback is an earlier version stored again
+	ABISourceRollback = "rollback"
+)
+
+// ABIVersion is a version of the abi of a contract
+type ABIVersion struct {
+	ID        int64  `json:"id"`
+	Contract  string `json:"contract"`
+	ABI       string `json:"abi"`
+	Source    string `json:"source"`
+	Author    string `json:"author"`
+	CreatedAt int64  `json:"createdAt"`
+}
+
+// kinds of abi changes
+const (
+	ABIChangeAdded   = "added"
+	ABIChangeRemoved = "removed"
+	ABIChangeChanged = "changed"
+)
+
+// ABIChange is an added, removed or changed entry of an abi, signatures are human readable
+type ABIChange struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Signature string `json:"signature,omitempty"`
+	Previous  string `json:"previous,omitempty"`
+}
+
+// ABIDiff is a semantic diff between two abi versions of a contract
+type ABIDiff struct {
+	Contract  string      `json:"contract"`
+	From      int64       `json:"from"`
+	To        int64       `json:"to"`
+	Functions []ABIChange `json:"functions"`
+	Events    []ABIChange `json:"events"`
+	Errors    []ABIChange `json:"errors"`
+}
@@ -0,0 +1,267 @@
+package core
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	ethereum "github.com/ethereum/go-ethereum/common"
+
+	"github.com/KyberNetwork/contract-caller/common"
+)
+
+// abiEntry is a raw abi entry, errors are read here since the abi package does not know them
+type abiEntry struct {
+	Type            string     `json:"type"`
+	Name            string     `json:"name"`
+	Inputs          []abiParam `json:"inputs"`
+	Outputs         []abiParam `json:"outputs"`
+	StateMutability string     `json:"stateMutability"`
+	Constant        bool       `json:"constant"`
+	Payable         bool       `json:"payable"`
+	Anonymous       bool       `json:"anonymous"`
+}
+
+// ABIFromArtifact returns abi of a compiler artifact (an object holding an abi field) and its source, other
+// abis are returned as is with user source
+func ABIFromArtifact(raw string) (string, string) {
+	var artifact struct {
+		ABI json.RawMessage `json:"abi"`
+	}
+	trimmed := strings.TrimSpace(raw)
+	if strings.HasPrefix(trimmed, "{") && json.Unmarshal([]byte(trimmed), &artifact) == nil && len(artifact.ABI) != 0 {
+		return string(artifact.ABI), common.ABISourceArtifact
+	}
+	return raw, common.ABISourceUser
+}
+
+// paramType is canonical type of a param, tuples are written as their components
+func paramType(p abiParam) string {
+	if !strings.HasPrefix(p.Type, "tuple") {
+		return p.Type
+	}
+	types := make([]string, 0, len(p.Components))
+	for _, c := range p.Components {
+		types = append(types, paramType(c))
+	}
+	return "(" + strings.Join(types, ",") + ")" + strings.TrimPrefix(p.Type, "tuple")
+}
+
+func paramList(params []abiParam, event bool) string {
+	types := make([]string, 0, len(params))
+	for _, p := range params {
+		t := paramType(p)
+		if event && p.Indexed {
+			t += " indexed"
+		}
+		types = append(types, t)
+	}
+	return "(" + strings.Join(types, ",") + ")"
+}
+
+// selector is the name and input types which identify a function, event or error
+func (e abiEntry) selector() string {
+	return e.Name + paramList(e.Inputs, false)
+}
+
+// signature is a human readable description of the entry, entries are changed when it changes
+func (e abiEntry) signature() string {
+	switch e.Type {
+	case "event":
+		s := "event " + e.Name + paramList(e.Inputs, true)
+		if e.Anonymous {
+			s += " anonymous"
+		}
+		return s
+	case "error":
+		return "error " + e.Name + paramList(e.Inputs, false)
+	}
+	mutability := e.StateMutability
+	if mutability == "" {
+		switch {
+		case e.Constant:
+			mutability = "view"
+		case e.Payable:
+			mutability = "payable"
+		default:
+			mutability = "nonpayable"
+		}
+	}
+	s := "function " + e.selector() + " " + mutability
+	if len(e.Outputs) != 0 {
+		s += " returns " + paramList(e.Outputs, false)
+	}
+	return s
+}
+
+// parseABIEntries returns functions, events and errors of an abi by kind
+func parseABIEntries(rawABI string) (map[string][]abiEntry, error) {
+	var entries []abiEntry
+	if err := json.Unmarshal([]byte(rawABI), &entries); err != nil {
+		return nil, argumentError("abi", "cannot read abi, err: %s", err.Error())
+	}
+	byKind := make(map[string][]abiEntry)
+	for _, e := range entries {
+		if e.Type == "" {
+			e.Type = "function"
+		}
+		if e.Type == "function" || e.Type == "event" || e.Type == "error" {
+			byKind[e.Type] = append(byKind[e.Type], e)
+		}
+	}
+	return byKind, nil
+}
+
+// diffEntries compares entries of one kind. Entries are matched by selector, an entry whose name has a single
+// entry in both abis is changed even if its inputs change
+func diffEntries(from, to []abiEntry) []common.ABIChange {
+	byName := func(entries []abiEntry) map[string][]abiEntry {
+		m := make(map[string][]abiEntry)
+		for _, e := range entries {
+			m[e.Name] = append(m[e.Name], e)
+		}
+		return m
+	}
+	fromByName, toByName := byName(from), byName(to)
+	names := make(map[string]bool)
+	for name := range fromByName {
+		names[name] = true
+	}
+	for name := range toByName {
+		names[name] = true
+	}
+	changes := []common.ABIChange{}
+	for name := range names {
+		olds, news := fromByName[name], toByName[name]
+		if len(olds) == 1 && len(news) == 1 {
+			if olds[0].signature() != news[0].signature() {
+				changes = append(changes, common.ABIChange{Kind: common.ABIChangeChanged, Name: name,
+					Signature: news[0].signature(), Previous: olds[0].signature()})
+			}
+			continue
+		}
+		oldBySelector := make(map[string]abiEntry)
+		for _, e := range olds {
+			oldBySelector[e.selector()] = e
+		}
+		for _, e := range news {
+			old, ok := oldBySelector[e.selector()]
+			switch {
+			case !ok:
+				changes = append(changes, common.ABIChange{Kind: common.ABIChangeAdded, Name: name, Signature: e.signature()})
+			case old.signature() != e.signature():
+				changes = append(changes, common.ABIChange{Kind: common.ABIChangeChanged, Name: name,
+					Signature: e.signature(), Previous: old.signature()})
+			}
+			delete(oldBySelector, e.selector())
+		}
+		for _, e := range oldBySelector {
+			changes = append(changes, common.ABIChange{Kind: common.ABIChangeRemoved, Name: name, Previous: e.signature()})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Name != changes[j].Name {
+			return changes[i].Name < changes[j].Name
+		}
+		return changes[i].Signature+changes[i].Previous < changes[j].Signature+changes[j].Previous
+	})
+	return changes
+}
+
+// DiffABI returns added, removed and changed functions, events and errors from one abi to another
+func DiffABI(fromABI, toABI string) (common.ABIDiff, error) {
+	from, err := parseABIEntries(fromABI)
+	if err != nil {
+		return common.ABIDiff{}, err
+	}
+	to, err := parseABIEntries(toABI)
+	if err != nil {
+		return common.ABIDiff{}, err
+	}
+	return common.ABIDiff{
+		Functions: diffEntries(from["function"], to["function"]),
+		Events:    diffEntries(from["event"], to["event"]),
+		Errors:    diffEntries(from["error"], to["error"]),
+	}, nil
+}
+
+// abiSource returns source of an abi looked up for contract, the recorded source if it is the stored abi and
+// etherscan otherwise
+func (c *Core) abiSource(contract ethereum.Address, contractABI string) (string, error) {
+	versions, err := c.s.GetABIVersions(contract.Hex())
+	if err != nil {
+		return "", err
+	}
+	if len(versions) != 0 && versions[0].ABI == contractABI {
+		return versions[0].Source, nil
+	}
+	return common.ABISourceEtherscan, nil
+}
+
+// ABIVersions returns abi versions of contract, latest first
+func (c *Core) ABIVersions(contract ethereum.Address) ([]common.ABIVersion, error) {
+	return c.s.GetABIVersions(contract.Hex())
+}
+
+// RollbackABI stores an earlier abi version of contract again, as a new version written by actor
+func (c *Core) RollbackABI(contract ethereum.Address, version int64, actor string) (common.ABIVersion, error) {
+	v, err := c.s.GetABIVersion(contract.Hex(), version)
+	if err != nil {
+		return common.ABIVersion{}, err
+	}
+	if v == nil {
+		return common.ABIVersion{}, notFoundError("no abi version %d of contract %s", version, contract.Hex())
+	}
+	if err := c.s.StoreContractABI(contract, v.ABI, common.ABISourceRollback, actor); err != nil {
+		return common.ABIVersion{}, err
+	}
+	versions, err := c.s.GetABIVersions(contract.Hex())
+	if err != nil {
+		return common.ABIVersion{}, err
+	}
+	return versions[0], nil
+}
+
+// DiffABIVersions diffs two abi versions of contract, to is the latest version and from is the version before
+// to when they are zero
+func (c *Core) DiffABIVersions(contract ethereum.Address, from, to int64) (common.ABIDiff, error) {
+	versions, err := c.s.GetABIVersions(contract.Hex())
+	if err != nil {
+		return common.ABIDiff{}, err
+	}
+	if len(versions) == 0 {
+		return common.ABIDiff{}, notFoundError("no abi version of contract %s", contract.Hex())
+	}
+	find := func(argument string, id int64) (int, error) {
+		for i, v := range versions {
+			if v.ID == id {
+				return i, nil
+			}
+		}
+		e := notFoundError("no abi version %d of contract %s", id, contract.Hex())
+		e.Details = map[string]interface{}{"argument": argument}
+		return 0, e
+	}
+	toIndex := 0
+	if to != 0 {
+		if toIndex, err = find("to", to); err != nil {
+			return common.ABIDiff{}, err
+		}
+	}
+	fromIndex := toIndex + 1
+	if from != 0 {
+		if fromIndex, err = find("from", from); err != nil {
+			return common.ABIDiff{}, err
+		}
+	} else if fromIndex == len(versions) {
+		return common.ABIDiff{}, argumentError("from", "version %d is the first abi version of contract %s",
+			versions[toIndex].ID, contract.Hex())
+	}
+	diff, err := DiffABI(versions[fromIndex].ABI, versions[toIndex].ABI)
+	if err != nil {
+		return common.ABIDiff{}, err
+	}
+	diff.Contract = contract.Hex()
+	diff.From, diff.To = versions[fromIndex].ID, versions[toIndex].ID
+	return diff, nil
+}
@@ -0,0 +1,52 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/KyberNetwork/contract-caller/common"
+)
+
+func TestDiffABI(t *testing.T) {
+	const (
+		v1 = `[
+			{"type":"function","name":"owner","inputs":[],"outputs":[{"name":"","type":"address"}],"stateMutability":"view"},
+			{"type":"function","name":"balanceOf","inputs":[{"name":"a","type":"address"}],"outputs":[{"name":"","type":"uint256"}],"constant":true},
+			{"type":"function","name":"pause","inputs":[],"outputs":[]},
+			{"type":"event","name":"Transfer","inputs":[{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"value","type":"uint256"}]}
+		]`
+		v2 = `{"contractName":"Token","abi":[
+			{"type":"function","name":"owner","inputs":[],"outputs":[{"name":"","type":"address"}],"stateMutability":"view"},
+			{"type":"function","name":"balanceOf","inputs":[{"name":"a","type":"address"}],"outputs":[{"name":"","type":"uint256"}],"stateMutability":"view"},
+			{"type":"function","name":"balanceOf","inputs":[{"name":"a","type":"address"},{"name":"id","type":"uint256"}],"outputs":[{"name":"","type":"uint256"}],"stateMutability":"view"},
+			{"type":"function","name":"upgradeTo","inputs":[{"name":"impl","type":"address"}],"outputs":[],"stateMutability":"nonpayable"},
+			{"type":"event","name":"Transfer","inputs":[{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"value","type":"uint256","indexed":true}]},
+			{"type":"error","name":"Unauthorized","inputs":[{"name":"caller","type":"tuple","components":[{"name":"a","type":"address"},{"name":"b","type":"uint8"}]}]}
+		]}`
+	)
+	toABI, source := ABIFromArtifact(v2)
+	require.Equal(t, common.ABISourceArtifact, source)
+	_, source = ABIFromArtifact(v1)
+	require.Equal(t, common.ABISourceUser, source)
+
+	diff, err := DiffABI(v1, toABI)
+	require.NoError(t, err)
+	require.Equal(t, []common.ABIChange{
+		{Kind: common.ABIChangeAdded, Name: "balanceOf",
+			Signature: "function balanceOf(address,uint256) view returns (uint256)"},
+		{Kind: common.ABIChangeRemoved, Name: "pause", Previous: "function pause() nonpayable"},
+		{Kind: common.ABIChangeAdded, Name: "upgradeTo", Signature: "function upgradeTo(address) nonpayable"},
+	}, diff.Functions)
+	require.Equal(t, []common.ABIChange{
+		{Kind: common.ABIChangeChanged, Name: "Transfer",
+			Signature: "event Transfer(address indexed,address indexed,uint256 indexed)",
+			Previous:  "event Transfer(address indexed,address indexed,uint256)"},
+	}, diff.Events)
+	require.Equal(t, []common.ABIChange{
+		{Kind: common.ABIChangeAdded, Name: "Unauthorized", Signature: "error Unauthorized((address,uint8))"},
+	}, diff.Errors)
+
+	_, err = DiffABI("not json", toABI)
+	require.Equal(t, CodeValidation, AsError(err).Code)
+}
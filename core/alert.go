@@ -0,0 +1,213 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/http"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum/common"
+
+	"github.com/KyberNetwork/contract-caller/common"
+	libhttp "github.com/KyberNetwork/contract-caller/lib/http"
+	"github.com/KyberNetwork/contract-caller/lib/render"
+)
+
+const (
+	// webhookTimeout is timeout of a webhook delivery
+	webhookTimeout = 10 * time.Second
+	// webhookRetries is number of retries of a webhook delivery failing on network, 429 or 5xx
+	webhookRetries = 2
+)
+
+// CreateAlert validates and stores a new alert rule
+func (c *Core) CreateAlert(a common.Alert) (int64, error) {
+	if !ethereum.IsHexAddress(a.Contract) {
+		return 0, argumentError("contract", "contract is not a valid ethereum address")
+	}
+	a.Contract = ethereum.HexToAddress(a.Contract).Hex()
+	switch a.Condition {
+	case common.AlertConditionDeviation, common.AlertConditionGreaterThan, common.AlertConditionLessThan:
+		if _, ok := new(big.Float).SetString(a.Threshold); !ok {
+			return 0, argumentError("threshold", "threshold must be a number, condition=%s, threshold=%s", a.Condition, a.Threshold)
+		}
+	case common.AlertConditionEquals:
+	default:
+		return 0, argumentError("condition", "unsupported condition, condition=%s", a.Condition)
+	}
+	switch a.WebhookFormat {
+	case "":
+		a.WebhookFormat = common.WebhookFormatJSON
+	case common.WebhookFormatJSON, common.WebhookFormatSlack:
+	default:
+		return 0, argumentError("webhookFormat", "unsupported webhook format, format=%s", a.WebhookFormat)
+	}
+	if a.WebhookURL == "" {
+		return 0, argumentError("webhookURL", "webhook url is required")
+	}
+	if err := c.nodes.checkWebhook("webhookURL", a.WebhookURL); err != nil {
+		return 0, err
+	}
+	// alerts are evaluated on the default node
+	if a.Network == "" {
+		a.Network = c.network
+	}
+	if a.Network != c.network {
+		return 0, argumentError("network", "alerts can only watch network of the server node, network=%s, node network=%s",
+			a.Network, c.network)
+	}
+	return c.s.CreateAlert(a)
+}
+
+// Alerts returns all alert rules
+func (c *Core) Alerts() ([]common.Alert, error) {
+	return c.s.GetAlerts()
+}
+
+// DeleteAlert ...
+func (c *Core) DeleteAlert(id int64) error {
+	return c.s.DeleteAlert(id)
+}
+
+// RunAlerts evaluates all alerts every interval until ctx is done
+func (c *Core) RunAlerts(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		c.evaluateAlerts(ctx)
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (c *Core) evaluateAlerts(ctx context.Context) {
+	l := c.l.With("func", "core/evaluateAlerts")
+	alerts, err := c.s.GetAlerts()
+	if err != nil {
+		l.Errorw("cannot get alerts", "err", err)
+		return
+	}
+	for _, a := range alerts {
+		if err := c.evaluateAlert(ctx, a); err != nil {
+			l.Errorw("cannot evaluate alert", "id", a.ID, "name", a.Name, "err", err)
+		}
+	}
+}
+
+// evaluateAlert calls the alert method, notifies on state transition only and stores new state
+func (c *Core) evaluateAlert(ctx context.Context, a common.Alert) error {
+	if a.Network != c.network {
+		return fmt.Errorf("alert network is not network of the server node, network=%s, node network=%s",
+			a.Network, c.network)
+	}
+	contract := ethereum.HexToAddress(a.Contract)
+	contractABI, err := c.ContractABI(contract, a.Network)
+	if err != nil {
+		return err
+	}
+	result, err := c.callContract(c.ecli, contract, contractABI, a.Method, nil, a.Params)
+	if err != nil {
+		return err
+	}
+	outputs, ok := result.([]interface{})
+	if !ok || len(outputs) == 0 {
+		return fmt.Errorf("method returns no output, method=%s", a.Method)
+	}
+	value := render.FormatValue(outputs[0])
+	triggered, err := alertTriggered(a, value)
+	if err != nil {
+		return err
+	}
+	if triggered != a.Triggered {
+		if err := c.notify(ctx, a, value, triggered); err != nil {
+			// keep old state so the notification is retried on next evaluation
+			return err
+		}
+	}
+	return c.s.UpdateAlertState(a.ID, value, triggered)
+}
+
+// alertTriggered checks value against alert condition
+func alertTriggered(a common.Alert, value string) (bool, error) {
+	if a.Condition == common.AlertConditionEquals {
+		return value == a.Threshold, nil
+	}
+	v, ok := new(big.Float).SetString(value)
+	if !ok {
+		return false, fmt.Errorf("value is not a number, value=%s", value)
+	}
+	threshold, _ := new(big.Float).SetString(a.Threshold)
+	switch a.Condition {
+	case common.AlertConditionGreaterThan:
+		return v.Cmp(threshold) > 0, nil
+	case common.AlertConditionLessThan:
+		return v.Cmp(threshold) < 0, nil
+	case common.AlertConditionDeviation:
+		if a.LastValue == "" {
+			return false, nil
+		}
+		last, ok := new(big.Float).SetString(a.LastValue)
+		if !ok || last.Sign() == 0 {
+			return false, nil
+		}
+		// deviation = |v - last| * 100 / |last|
+		deviation := new(big.Float).Sub(v, last)
+		deviation.Abs(deviation).Mul(deviation, big.NewFloat(100)).Quo(deviation, new(big.Float).Abs(last))
+		return deviation.Cmp(threshold) > 0, nil
+	default:
+		return false, fmt.Errorf("unsupported condition, condition=%s", a.Condition)
+	}
+}
+
+type alertPayload struct {
+	ID        int64  `json:"id"`
+	Name      string `json:"name"`
+	Contract  string `json:"contract"`
+	Network   string `json:"network"`
+	Method    string `json:"method"`
+	Condition string `json:"condition"`
+	Threshold string `json:"threshold"`
+	Value     string `json:"value"`
+	Previous  string `json:"previous"`
+	Status    string `json:"status"`
+	Time      int64  `json:"time"`
+}
+
+type slackPayload struct {
+	Text string `json:"text"`
+}
+
+// notify delivers triggered or recovered notification of alert to its webhook
+func (c *Core) notify(ctx context.Context, a common.Alert, value string, triggered bool) error {
+	status := "recovered"
+	if triggered {
+		status = "triggered"
+	}
+	var payload interface{}
+	switch a.WebhookFormat {
+	case common.WebhookFormatSlack:
+		payload = slackPayload{
+			Text: fmt.Sprintf("[%s] alert %q: %s.%s() = %s (previous %s, condition %s %s)",
+				status, a.Name, a.Contract, a.Method, value, a.LastValue, a.Condition, a.Threshold),
+		}
+	default:
+		payload = alertPayload{
+			ID:        a.ID,
+			Name:      a.Name,
+			Contract:  a.Contract,
+			Network:   a.Network,
+			Method:    a.Method,
+			Condition: a.Condition,
+			Threshold: a.Threshold,
+			Value:     value,
+			Previous:  a.LastValue,
+			Status:    status,
+			Time:      time.Now().Unix(),
+		}
+	}
+	return c.webhook.Do(ctx, libhttp.Request{Method: http.MethodPost, URL: a.WebhookURL, Body: payload}, nil)
+}
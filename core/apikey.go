@@ -0,0 +1,114 @@
+package core
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum/common"
+
+	"github.com/KyberNetwork/contract-caller/common"
+)
+
+const (
+	// apiKeyPrefix starts all api keys so they are easy to recognize in configs and logs
+	apiKeyPrefix = "cc_"
+	// apiKeyShownLength is length of the part of a key kept in clear to identify it
+	apiKeyShownLength = len(apiKeyPrefix) + 8
+	// maxABIAuditLimit is the largest number of abi writes returned at once
+	maxABIAuditLimit = 500
+)
+
+// roleRanks orders roles, a role is allowed what lower roles are
+var roleRanks = map[string]int{
+	common.RoleViewer: 1,
+	common.RoleEditor: 2,
+	common.RoleAdmin:  3,
+}
+
+// ValidRole tells whether role is a known role
+func ValidRole(role string) bool {
+	_, ok := roleRanks[role]
+	return ok
+}
+
+// HasRole tells whether role is allowed what required role is
+func HasRole(role, required string) bool {
+	return ValidRole(role) && roleRanks[role] >= roleRanks[required]
+}
+
+// NewAPIKey returns a random api key secret and the key to store, the secret is only known by the caller
+func NewAPIKey(name, role string, rateLimit float64, dailyQuota int64) (string, common.APIKey, error) {
+	if name == "" {
+		return "", common.APIKey{}, argumentError("name", "api key name is required")
+	}
+	if !ValidRole(role) {
+		return "", common.APIKey{}, argumentError("role", "unknown role, role=%s, available=%s,%s,%s",
+			role, common.RoleViewer, common.RoleEditor, common.RoleAdmin)
+	}
+	if rateLimit < 0 || dailyQuota < 0 {
+		return "", common.APIKey{}, validationError("rate limit and daily quota must not be negative")
+	}
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", common.APIKey{}, err
+	}
+	secret := apiKeyPrefix + hex.EncodeToString(b)
+	return secret, common.APIKey{
+		Name:       name,
+		Prefix:     secret[:apiKeyShownLength],
+		Role:       role,
+		RateLimit:  rateLimit,
+		DailyQuota: dailyQuota,
+		CreatedAt:  time.Now().Unix(),
+	}, nil
+}
+
+// HashAPIKey returns hash of an api key secret as stored in db
+func HashAPIKey(secret string) string {
+	h := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(h[:])
+}
+
+// Authenticate returns the api key of a secret
+func (c *Core) Authenticate(secret string) (common.APIKey, error) {
+	k, err := c.s.GetAPIKeyByHash(HashAPIKey(secret))
+	if err != nil {
+		return common.APIKey{}, err
+	}
+	if k == nil || k.Revoked {
+		return common.APIKey{}, &Error{Code: CodeUnauthorized, Message: "api key is invalid or revoked"}
+	}
+	return *k, nil
+}
+
+// UseAPIKey counts a request of k, failing when its daily quota is used up
+func (c *Core) UseAPIKey(k common.APIKey) error {
+	count, err := c.s.IncreaseAPIKeyUsage(k.ID, time.Now().UTC().Format("2006-01-02"))
+	if err != nil {
+		return err
+	}
+	if k.DailyQuota != 0 && count > k.DailyQuota {
+		return &Error{
+			Code:    CodeRateLimited,
+			Message: "daily quota of api key is used up",
+			Details: map[string]interface{}{"dailyQuota": k.DailyQuota},
+		}
+	}
+	return nil
+}
+
+// ABIAuditLog returns latest abi writes, of contract if it is not empty
+func (c *Core) ABIAuditLog(contract string, limit int) ([]common.ABIAudit, error) {
+	if contract != "" {
+		if !ethereum.IsHexAddress(contract) {
+			return nil, argumentError("contract", "contract is not a valid ethereum address")
+		}
+		contract = ethereum.HexToAddress(contract).Hex()
+	}
+	if limit <= 0 || limit > maxABIAuditLimit {
+		limit = maxABIAuditLimit
+	}
+	return c.s.GetABIAuditLog(contract, limit)
+}
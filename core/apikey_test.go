@@ -0,0 +1,22 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/KyberNetwork/contract-caller/common"
+)
+
+func TestHasRole(t *testing.T) {
+	require.True(t, HasRole(common.RoleAdmin, common.RoleEditor))
+	require.True(t, HasRole(common.RoleEditor, common.RoleEditor))
+	require.False(t, HasRole(common.RoleViewer, common.RoleEditor))
+	require.False(t, HasRole("", common.RoleViewer))
+
+	_, _, err := NewAPIKey("alice", "owner", 0, 0)
+	require.Equal(t, CodeValidation, AsError(err).Code)
+	_, k, err := NewAPIKey("alice", common.RoleEditor, 0, 0)
+	require.NoError(t, err)
+	require.Equal(t, common.RoleEditor, k.Role)
+}
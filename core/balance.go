@@ -0,0 +1,254 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	ethereum "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"github.com/KyberNetwork/contract-caller/common"
+	"github.com/KyberNetwork/contract-caller/lib/render"
+)
+
+// maxBalanceCells is the largest number of balances and allowances of a matrix, the same as requests of a
+// json-rpc batch
+const maxBalanceCells = 100
+
+// nativeSymbol returns symbol of native coin of network
+func nativeSymbol(network string) string {
+	if n, ok := common.NetworkByName(network); ok {
+		return n.NativeSymbol
+	}
+	return "ETH"
+}
+
+// balanceCaller reads balances at a fixed block
+type balanceCaller interface {
+	// aggregate executes raw calls, a failing call does not fail the others
+	aggregate(calls []multicallCall) ([]callOutcome, error)
+	// balanceAt returns native balance of holder
+	balanceAt(holder ethereum.Address) (*big.Int, error)
+}
+
+// nodeBalanceCaller reads balances from a node at block bn
+type nodeBalanceCaller struct {
+	c       *Core
+	eclient *ethclient.Client
+	bn      *big.Int
+}
+
+func (n nodeBalanceCaller) aggregate(calls []multicallCall) ([]callOutcome, error) {
+	return n.c.aggregate(n.eclient, n.bn, calls)
+}
+
+func (n nodeBalanceCaller) balanceAt(holder ethereum.Address) (*big.Int, error) {
+	return n.eclient.BalanceAt(context.Background(), holder, n.bn)
+}
+
+// BalanceMatrix returns balances of every holder for every token (address or common.NativeToken) at a block,
+// with totals per token, and allowances every holder gave every spender for erc20 tokens. Calls are fetched
+// through Multicall3 in chunks.
+func (c *Core) BalanceMatrix(holders, tokens, spenders []string, blockNumber, customNode string) (common.BalanceMatrix, error) {
+	var matrix common.BalanceMatrix
+	if cells := len(holders) * len(tokens) * (1 + len(spenders)); cells > maxBalanceCells {
+		e := argumentError("holders", "too many balances, holders*tokens*(1+spenders)=%d, max=%d", cells, maxBalanceCells)
+		e.Details["max"] = maxBalanceCells
+		return matrix, e
+	}
+	for _, h := range holders {
+		if !ethereum.IsHexAddress(h) {
+			return matrix, argumentError("holders", "holder is not a valid ethereum address, holder=%s", h)
+		}
+		matrix.Holders = append(matrix.Holders, ethereum.HexToAddress(h).Hex())
+	}
+	for _, t := range tokens {
+		if t != common.NativeToken && !ethereum.IsHexAddress(t) {
+			return matrix, argumentError("tokens", "token is not a valid ethereum address, token=%s", t)
+		}
+	}
+	for _, s := range spenders {
+		if !ethereum.IsHexAddress(s) {
+			return matrix, argumentError("spenders", "spender is not a valid ethereum address, spender=%s", s)
+		}
+		matrix.Spenders = append(matrix.Spenders, ethereum.HexToAddress(s).Hex())
+	}
+	bn, err := ParseBlockNumber(blockNumber)
+	if err != nil {
+		return matrix, err
+	}
+	eclient, err := c.nodeClient(customNode)
+	if err != nil {
+		return matrix, err
+	}
+	if eclient != c.ecli {
+		defer eclient.Close()
+	}
+	if bn == nil {
+		head, err := eclient.HeaderByNumber(context.Background(), nil)
+		if err != nil {
+			return matrix, upstreamError(err, "cannot get latest block, err=%s", err)
+		}
+		bn = head.Number
+	}
+	matrix.BlockNumber = bn.Uint64()
+	network, err := networkFromNode(eclient)
+	if err != nil {
+		return matrix, err
+	}
+	caller := nodeBalanceCaller{c: c, eclient: eclient, bn: bn}
+	if err := readBalances(&matrix, caller, c.hasMulticall(eclient, bn), network, tokens); err != nil {
+		return matrix, err
+	}
+	return matrix, nil
+}
+
+// readBalances fills tokens, balances and allowances of matrix, whose holders and spenders are set. Native
+// balances fall back to balanceAt when multicall is not deployed
+func readBalances(matrix *common.BalanceMatrix, caller balanceCaller, useMulticall bool, network string,
+	tokens []string) error {
+	tABI, err := abi.JSON(strings.NewReader(tokenABI))
+	if err != nil {
+		return err
+	}
+	mABI, err := abi.JSON(strings.NewReader(multicallABI))
+	if err != nil {
+		return err
+	}
+
+	// metadata: decimals and symbol of every erc20 token
+	var metaCalls []multicallCall
+	decimalsData, _ := tABI.Pack("decimals")
+	symbolData, _ := tABI.Pack("symbol")
+	for _, t := range tokens {
+		if t == common.NativeToken {
+			continue
+		}
+		token := ethereum.HexToAddress(t)
+		metaCalls = append(metaCalls, multicallCall{Target: token, CallData: decimalsData},
+			multicallCall{Target: token, CallData: symbolData})
+	}
+	metaOut, err := caller.aggregate(metaCalls)
+	if err != nil {
+		return err
+	}
+	for _, t := range tokens {
+		if t == common.NativeToken {
+			matrix.Tokens = append(matrix.Tokens, common.BalanceToken{
+				Address:  common.NativeToken,
+				Symbol:   nativeSymbol(network),
+				Decimals: 18,
+			})
+			continue
+		}
+		bt := common.BalanceToken{Address: ethereum.HexToAddress(t).Hex()}
+		decimalsOut, symbolOut := metaOut[0], metaOut[1]
+		metaOut = metaOut[2:]
+		if out, err := tABI.Unpack("decimals", decimalsOut.data); decimalsOut.success && err == nil {
+			bt.Decimals, _ = out[0].(uint8)
+		} else {
+			bt.Err = "cannot get decimals, token may not be erc20"
+		}
+		if out, err := tABI.Unpack("symbol", symbolOut.data); symbolOut.success && err == nil {
+			bt.Symbol, _ = out[0].(string)
+		}
+		matrix.Tokens = append(matrix.Tokens, bt)
+	}
+
+	// balances then allowances, in one batch
+	var (
+		calls  []multicallCall
+		cells  = make([][]common.BalanceCell, len(matrix.Holders))
+		totals = make([]*big.Int, len(tokens))
+	)
+	for j := range tokens {
+		totals[j] = new(big.Int)
+	}
+	for i, h := range matrix.Holders {
+		holder := ethereum.HexToAddress(h)
+		cells[i] = make([]common.BalanceCell, len(tokens))
+		for _, t := range tokens {
+			switch {
+			case t != common.NativeToken:
+				data, _ := tABI.Pack("balanceOf", holder)
+				calls = append(calls, multicallCall{Target: ethereum.HexToAddress(t), CallData: data})
+			case useMulticall:
+				data, _ := mABI.Pack("getEthBalance", holder)
+				calls = append(calls, multicallCall{Target: ethereum.HexToAddress(multicallAddress), CallData: data})
+			}
+		}
+	}
+	for _, h := range matrix.Holders {
+		for _, s := range matrix.Spenders {
+			for _, t := range tokens {
+				if t == common.NativeToken {
+					continue
+				}
+				data, _ := tABI.Pack("allowance", ethereum.HexToAddress(h), ethereum.HexToAddress(s))
+				calls = append(calls, multicallCall{Target: ethereum.HexToAddress(t), CallData: data})
+			}
+		}
+	}
+	outs, err := caller.aggregate(calls)
+	if err != nil {
+		return err
+	}
+	// next decodes the next uint256 output, balanceOf, getEthBalance and allowance all return one
+	next := func() (*big.Int, bool) {
+		o := outs[0]
+		outs = outs[1:]
+		out, err := tABI.Unpack("balanceOf", o.data)
+		if !o.success || err != nil {
+			return nil, false
+		}
+		value, ok := out[0].(*big.Int)
+		return value, ok
+	}
+	for i, h := range matrix.Holders {
+		for j, t := range tokens {
+			var (
+				balance *big.Int
+				ok      bool
+			)
+			if t == common.NativeToken && !useMulticall {
+				if balance, err = caller.balanceAt(ethereum.HexToAddress(h)); err != nil {
+					cells[i][j].Err = fmt.Sprintf("cannot get balance, err=%s", err)
+					continue
+				}
+			} else if balance, ok = next(); !ok {
+				cells[i][j].Err = "cannot get balance"
+				continue
+			}
+			totals[j].Add(totals[j], balance)
+			cells[i][j].Raw = balance.String()
+			cells[i][j].Formatted = render.FormatUnits(balance, matrix.Tokens[j].Decimals)
+		}
+	}
+	for j := range matrix.Tokens {
+		matrix.Tokens[j].Total = totals[j].String()
+		matrix.Tokens[j].TotalFormatted = render.FormatUnits(totals[j], matrix.Tokens[j].Decimals)
+	}
+	matrix.Balances = cells
+	for _, h := range matrix.Holders {
+		for _, s := range matrix.Spenders {
+			row := common.AllowanceRow{Holder: h, Spender: s, Allowances: make([]common.BalanceCell, len(tokens))}
+			for j, t := range tokens {
+				if t == common.NativeToken {
+					continue
+				}
+				allowance, ok := next()
+				if !ok {
+					row.Allowances[j].Err = "cannot get allowance"
+					continue
+				}
+				row.Allowances[j].Raw = allowance.String()
+				row.Allowances[j].Formatted = render.FormatUnits(allowance, matrix.Tokens[j].Decimals)
+			}
+			matrix.Allowances = append(matrix.Allowances, row)
+		}
+	}
+	return nil
+}
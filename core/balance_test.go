@@ -0,0 +1,132 @@
+package core
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	ethereum "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KyberNetwork/contract-caller/common"
+)
+
+// stubBalanceCaller answers token calls from maps, calls of other tokens fail
+type stubBalanceCaller struct {
+	tABI       abi.ABI
+	decimals   map[ethereum.Address]uint8
+	balances   map[ethereum.Address]map[ethereum.Address]*big.Int
+	allowances map[ethereum.Address]map[[2]ethereum.Address]*big.Int
+	native     map[ethereum.Address]*big.Int
+	calls      int
+}
+
+func (s *stubBalanceCaller) aggregate(calls []multicallCall) ([]callOutcome, error) {
+	s.calls += len(calls)
+	outcomes := make([]callOutcome, len(calls))
+	for i, call := range calls {
+		method, err := s.tABI.MethodById(call.CallData[:4])
+		if err != nil {
+			continue
+		}
+		args, err := method.Inputs.Unpack(call.CallData[4:])
+		if err != nil {
+			continue
+		}
+		decimals, ok := s.decimals[call.Target]
+		if !ok {
+			continue
+		}
+		var out []byte
+		switch method.Name {
+		case "decimals":
+			out, err = method.Outputs.Pack(decimals)
+		case "symbol":
+			out, err = method.Outputs.Pack("TKN")
+		case "balanceOf":
+			out, err = method.Outputs.Pack(s.balances[call.Target][args[0].(ethereum.Address)])
+		case "allowance":
+			key := [2]ethereum.Address{args[0].(ethereum.Address), args[1].(ethereum.Address)}
+			out, err = method.Outputs.Pack(s.allowances[call.Target][key])
+		default:
+			continue
+		}
+		if err == nil {
+			outcomes[i] = callOutcome{success: true, data: out}
+		}
+	}
+	return outcomes, nil
+}
+
+func (s *stubBalanceCaller) balanceAt(holder ethereum.Address) (*big.Int, error) {
+	if balance, ok := s.native[holder]; ok {
+		return balance, nil
+	}
+	return nil, errors.New("missing trie node")
+}
+
+func TestReadBalances(t *testing.T) {
+	tABI, err := abi.JSON(strings.NewReader(tokenABI))
+	require.NoError(t, err)
+	var (
+		alice   = ethereum.HexToAddress("0x00000000000000000000000000000000000a11ce")
+		bob     = ethereum.HexToAddress("0x0000000000000000000000000000000000000b0b")
+		router  = ethereum.HexToAddress("0x7a250d5630b4cf539739df2c5dacb4c659f2488d")
+		usdc    = ethereum.HexToAddress("0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48")
+		notERC  = ethereum.HexToAddress("0x0000000000000000000000000000000000000bad")
+		million = big.NewInt(1000000)
+	)
+	caller := &stubBalanceCaller{
+		tABI:     tABI,
+		decimals: map[ethereum.Address]uint8{usdc: 6},
+		balances: map[ethereum.Address]map[ethereum.Address]*big.Int{
+			usdc: {alice: big.NewInt(2500000), bob: million},
+		},
+		allowances: map[ethereum.Address]map[[2]ethereum.Address]*big.Int{
+			usdc: {{alice, router}: million, {bob, router}: big.NewInt(0)},
+		},
+		native: map[ethereum.Address]*big.Int{alice: big.NewInt(1e18)},
+	}
+	matrix := common.BalanceMatrix{
+		Holders:  []string{alice.Hex(), bob.Hex()},
+		Spenders: []string{router.Hex()},
+	}
+	tokens := []string{usdc.Hex(), common.NativeToken, notERC.Hex()}
+	require.NoError(t, readBalances(&matrix, caller, false, "mainnet", tokens))
+	// 2 metadata calls per erc20 token, a balance per holder and erc20 token, an allowance per holder, spender
+	// and erc20 token
+	require.Equal(t, 4+4+4, caller.calls)
+
+	require.Equal(t, "TKN", matrix.Tokens[0].Symbol)
+	require.Equal(t, uint8(6), matrix.Tokens[0].Decimals)
+	require.Equal(t, "3.5", matrix.Tokens[0].TotalFormatted)
+	require.Equal(t, "ETH", matrix.Tokens[1].Symbol)
+	require.NotEmpty(t, matrix.Tokens[2].Err)
+
+	require.Equal(t, "2.5", matrix.Balances[0][0].Formatted)
+	require.Equal(t, "1", matrix.Balances[0][1].Formatted)
+	require.NotEmpty(t, matrix.Balances[0][2].Err)
+	require.Equal(t, "1000000", matrix.Balances[1][0].Raw)
+	require.NotEmpty(t, matrix.Balances[1][1].Err)
+
+	require.Len(t, matrix.Allowances, 2)
+	require.Equal(t, alice.Hex(), matrix.Allowances[0].Holder)
+	require.Equal(t, router.Hex(), matrix.Allowances[0].Spender)
+	require.Equal(t, "1", matrix.Allowances[0].Allowances[0].Formatted)
+	require.Equal(t, common.BalanceCell{}, matrix.Allowances[0].Allowances[1])
+	require.Equal(t, "cannot get allowance", matrix.Allowances[0].Allowances[2].Err)
+	require.Equal(t, "0", matrix.Allowances[1].Allowances[0].Raw)
+}
+
+func TestBalanceMatrixLimit(t *testing.T) {
+	c := &Core{}
+	holders := make([]string, 10)
+	tokens := make([]string, 5)
+	_, err := c.BalanceMatrix(holders, tokens, []string{"0x01", "0x02"}, "", "")
+	require.Error(t, err)
+	e := AsError(err)
+	require.Equal(t, CodeValidation, e.Code)
+	require.Equal(t, maxBalanceCells, e.Details["max"])
+}
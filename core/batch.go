@@ -0,0 +1,176 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+
+	ethereum "github.com/ethereum/go-ethereum/common"
+	"gopkg.in/yaml.v2"
+
+	"github.com/KyberNetwork/contract-caller/common"
+)
+
+// defaultBatchNetwork is name of the implicit network of a batch without networks
+const defaultBatchNetwork = "default"
+
+// ParseBatch parses a batch file, yaml or json
+func ParseBatch(data []byte) (common.Batch, error) {
+	var b common.Batch
+	if err := yaml.Unmarshal(data, &b); err != nil {
+		return b, validationError("cannot parse batch, err: %s", err.Error())
+	}
+	return b, nil
+}
+
+// maxExactFloat is the largest integer every smaller integer of which a float64 holds exactly
+const maxExactFloat = 1 << 53
+
+// stringParams converts scalar param values decoded from yaml or json (numbers, bools) to strings. Numbers
+// decoded as floats are only kept when they are integers a float holds exactly, larger amounts must be quoted
+func stringParams(params map[string]interface{}) (map[string]interface{}, error) {
+	result := make(map[string]interface{}, len(params))
+	for k, v := range params {
+		switch n := v.(type) {
+		case nil, string:
+			result[k] = v
+		case float64:
+			if n != math.Trunc(n) || math.Abs(n) > maxExactFloat {
+				return nil, argumentError(k, "number of param %s cannot be kept exactly, quote it, param=%v", k, v)
+			}
+			result[k] = strconv.FormatFloat(n, 'f', -1, 64)
+		default:
+			result[k] = fmt.Sprint(v)
+		}
+	}
+	return result, nil
+}
+
+// batchContract is a resolved contract of a batch
+type batchContract struct {
+	alias   string
+	address ethereum.Address
+	network string
+	abi     string
+}
+
+// RunBatch executes all calls of a batch, networks concurrently, calls of a network through Multicall at
+// the same block. Results are in the order of the batch calls.
+func (c *Core) RunBatch(b common.Batch) ([]common.BatchResult, error) {
+	if len(b.Networks) == 0 {
+		b.Networks = []common.BatchNetwork{{Name: defaultBatchNetwork}}
+	}
+	networks := make(map[string]common.BatchNetwork, len(b.Networks))
+	for _, n := range b.Networks {
+		if _, ok := networks[n.Name]; ok {
+			return nil, validationError("duplicated network, network=%s", n.Name)
+		}
+		if _, err := ParseBlockNumber(n.Block); err != nil {
+			return nil, validationError("invalid block of network %s, err: %s", n.Name, err.Error())
+		}
+		networks[n.Name] = n
+	}
+	networkOf := func(name string) (string, error) {
+		if name == "" && len(b.Networks) == 1 {
+			return b.Networks[0].Name, nil
+		}
+		if _, ok := networks[name]; !ok {
+			return "", validationError("unknown network, network=%s", name)
+		}
+		return name, nil
+	}
+
+	contracts := make(map[string]*batchContract, len(b.Contracts))
+	for _, bc := range b.Contracts {
+		if _, ok := contracts[bc.Alias]; ok {
+			return nil, validationError("duplicated contract alias, alias=%s", bc.Alias)
+		}
+		if !ethereum.IsHexAddress(bc.Address) {
+			return nil, validationError("contract is not a valid ethereum address, alias=%s", bc.Alias)
+		}
+		network, err := networkOf(bc.Network)
+		if err != nil {
+			return nil, err
+		}
+		contracts[bc.Alias] = &batchContract{
+			alias:   bc.Alias,
+			address: ethereum.HexToAddress(bc.Address),
+			network: network,
+			abi:     bc.ABI,
+		}
+	}
+
+	var (
+		results   = make([]common.BatchResult, len(b.Calls))
+		calls     = make(map[string][]common.CallRequest)
+		indexes   = make(map[string][]int)
+		explorers = make(map[string]string)
+	)
+	for i, call := range b.Calls {
+		params, err := stringParams(call.Params)
+		if err != nil {
+			return nil, wrapError(err, "call %s: %s", call.Label, AsError(err).Message)
+		}
+		bc, ok := contracts[call.Contract]
+		if !ok {
+			if !ethereum.IsHexAddress(call.Contract) {
+				return nil, validationError("unknown contract alias, alias=%s", call.Contract)
+			}
+			network, err := networkOf("")
+			if err != nil {
+				return nil, validationError("contract %s without alias needs a single network batch", call.Contract)
+			}
+			bc = &batchContract{address: ethereum.HexToAddress(call.Contract), network: network}
+			contracts[call.Contract] = bc
+		}
+		results[i] = common.BatchResult{
+			Label:    call.Label,
+			Network:  bc.network,
+			Contract: bc.alias,
+			Address:  bc.address.Hex(),
+			Method:   call.Method,
+		}
+		if bc.abi == "" {
+			explorer, ok := explorers[bc.network]
+			if !ok {
+				var err error
+				if explorer, err = c.NetworkInfo(networks[bc.network].Node); err != nil {
+					return nil, upstreamError(err, "cannot get network info of %s, err: %s", bc.network, err.Error())
+				}
+				explorers[bc.network] = explorer
+			}
+			contractABI, err := c.ContractABI(bc.address, explorer)
+			if err != nil {
+				results[i].Err = err.Error()
+				continue
+			}
+			bc.abi = contractABI
+		}
+		calls[bc.network] = append(calls[bc.network], common.CallRequest{
+			Contract: bc.address.Hex(),
+			ABI:      bc.abi,
+			Method:   call.Method,
+			Params:   params,
+		})
+		indexes[bc.network] = append(indexes[bc.network], i)
+	}
+
+	var wg sync.WaitGroup
+	for name := range calls {
+		wg.Add(1)
+		go func(n common.BatchNetwork) {
+			defer wg.Done()
+			out, err := c.Multicall(calls[n.Name], n.Block, n.Node)
+			for j, i := range indexes[n.Name] {
+				if err != nil {
+					results[i].Err = err.Error()
+					continue
+				}
+				results[i].Result, results[i].Err = out[j].Result, out[j].Err
+			}
+		}(networks[name])
+	}
+	wg.Wait()
+	return results, nil
+}
@@ -0,0 +1,59 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBatch(t *testing.T) {
+	yamlBatch := `
+networks:
+  - name: mainnet
+    block: "11000000"
+contracts:
+  - alias: weth
+    address: "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2"
+    network: mainnet
+calls:
+  - label: weth supply
+    contract: weth
+    method: totalSupply
+  - label: holder balance
+    contract: weth
+    method: balanceOf
+    params:
+      owner: "0xbc5b5c036eb41a1a85af0b4da13d56420e8a0a92"
+`
+	b, err := ParseBatch([]byte(yamlBatch))
+	require.NoError(t, err)
+	require.Len(t, b.Networks, 1)
+	require.Equal(t, "11000000", b.Networks[0].Block)
+	require.Equal(t, "weth", b.Contracts[0].Alias)
+	require.Len(t, b.Calls, 2)
+	require.Equal(t, "0xbc5b5c036eb41a1a85af0b4da13d56420e8a0a92", b.Calls[1].Params["owner"])
+
+	jsonBatch := `{"calls": [{"label": "supply", "contract": "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2",
+		"method": "balanceOf", "params": {"id": 1}}]}`
+	b, err = ParseBatch([]byte(jsonBatch))
+	require.NoError(t, err)
+	require.Len(t, b.Calls, 1)
+	params, err := stringParams(b.Calls[0].Params)
+	require.NoError(t, err)
+	require.Equal(t, "1", params["id"])
+
+	// amounts beyond int64 are decoded as floats, they must be quoted
+	b, err = ParseBatch([]byte(`{"calls": [{"contract": "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2",
+		"method": "transfer", "params": {"to": "0x01", "amount": 1000000000000000000000}}]}`))
+	require.NoError(t, err)
+	_, err = stringParams(b.Calls[0].Params)
+	require.Error(t, err)
+	require.Equal(t, "amount", AsError(err).Details["argument"])
+
+	_, err = stringParams(map[string]interface{}{"amount": float64(1 << 52), "ok": true, "wad": 1.5e21})
+	require.Error(t, err)
+	params, err = stringParams(map[string]interface{}{"amount": float64(1 << 52), "ok": true})
+	require.NoError(t, err)
+	require.Equal(t, "4503599627370496", params["amount"])
+	require.Equal(t, "true", params["ok"])
+}
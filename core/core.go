@@ -9,6 +9,7 @@ import (
 	"github.com/KyberNetwork/contract-caller/common"
 	cc "github.com/KyberNetwork/contract-caller/lib/contract-caller"
 	"github.com/KyberNetwork/contract-caller/lib/etherscan"
+	libhttp "github.com/KyberNetwork/contract-caller/lib/http"
 	"github.com/KyberNetwork/contract-caller/storage"
 	"go.uber.org/zap"
 
@@ -17,7 +18,6 @@ import (
 	ethereum "github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/ethclient"
-	"github.com/pkg/errors"
 )
 
 // Core ...
@@ -27,6 +27,8 @@ type Core struct {
 	s       *storage.Storage
 	ecli    *ethclient.Client
 	network string
+	webhook *libhttp.RestClient
+	nodes   *nodeDialer
 }
 
 // networkFromNode returns network (difined by app) and network full name (readable)
@@ -35,20 +37,10 @@ func networkFromNode(ecli *ethclient.Client) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	switch chainID.Int64() {
-	case 1:
-		return common.EthereumMainnet, nil
-	case 3:
-		return common.EthereumRopsten, nil
-	case 42:
-		return common.EthereumKovan, nil
-	case 56:
-		return common.BSCMainnet, nil
-	case 97:
-		return common.BSCTestnet, nil
-	default:
-		return common.UnknowNetwork, nil
+	if network, ok := common.NetworkByChainID(chainID.Int64()); ok {
+		return network.Name, nil
 	}
+	return common.UnknowNetwork, nil
 }
 
 // NewCore ...
@@ -57,65 +49,133 @@ func NewCore(esc *etherscan.Etherscan, ecli *ethclient.Client, s *storage.Storag
 	if err != nil {
 		return nil, err
 	}
+	nodes, err := newNodeDialer(NodePolicy{})
+	if err != nil {
+		return nil, err
+	}
 	return &Core{
 		l:       zap.S(),
 		esc:     esc,
 		ecli:    ecli,
 		s:       s,
 		network: network,
+		webhook: nodes.webhookClient(),
+		nodes:   nodes,
 	}, nil
 }
 
+// SetNodePolicy sets policy of custom nodes and alert webhooks, by default only public addresses are allowed
+func (c *Core) SetNodePolicy(p NodePolicy) error {
+	nodes, err := newNodeDialer(p)
+	if err != nil {
+		return err
+	}
+	c.nodes, c.webhook = nodes, nodes.webhookClient()
+	return nil
+}
+
 // verifyContract ...
 func (c *Core) verifyContract(contract ethereum.Address) error {
 	code, err := c.ecli.CodeAt(context.Background(), contract, nil)
 	if err != nil {
-		return err
+		return upstreamError(err, "cannot get code of contract, err=%s", err)
 	}
 	if len(code) == 0 {
-		return errors.New("no code at given contract")
+		return notFoundError("no code at given contract")
 	}
 	return nil
 }
 
 // getContractABIFromEtherscan ...
 func (c *Core) getContractABIFromEtherscan(contract ethereum.Address, network string) (string, error) {
-	return c.esc.GetContractABI(contract, network)
+	source, err := c.getContractSourceFromEtherscan(contract, network)
+	if err != nil {
+		return "", err
+	}
+	return source.ABI, nil
 }
 
-// ContractMethods ...
-func (c *Core) ContractMethods(contract ethereum.Address, contractABI string, rememberABI bool, network string) ([]common.Method, error) {
-	l := c.l.With("func", "core/ContractMethods", "contract", contract.Hex())
-	if len(contractABI) == 0 {
-		var (
-			rawABI string
-			err    error
-		)
-		rawABI, err = c.s.GetContractABI(contract)
+// getContractSourceFromEtherscan fetches source metadata of a verified contract and stores it
+func (c *Core) getContractSourceFromEtherscan(contract ethereum.Address, network string) (common.ContractSource, error) {
+	source, err := c.esc.GetSourceCode(context.Background(), contract, network)
+	if err != nil {
+		return common.ContractSource{}, err
+	}
+	if err := c.s.StoreContractSource(source); err != nil {
+		c.l.Errorw("cannot store contract source", "contract", contract.Hex(), "err", err)
+	}
+	return source, nil
+}
+
+// ContractSource returns source metadata of a verified contract, from storage or etherscan
+func (c *Core) ContractSource(contract ethereum.Address, network string) (common.ContractSource, error) {
+	stored, err := c.s.GetContractSource(contract)
+	if err != nil {
+		c.l.Errorw("cannot get contract source from storage", "contract", contract.Hex(), "err", err)
+	}
+	if stored != nil {
+		return *stored, nil
+	}
+	source, err := c.getContractSourceFromEtherscan(contract, network)
+	if err != nil {
+		return common.ContractSource{}, fmt.Errorf("cannot get contract source, err: %w", err)
+	}
+	return source, nil
+}
+
+// ContractABI returns abi of given contract from storage, falls back to etherscan
+func (c *Core) ContractABI(contract ethereum.Address, network string) (string, error) {
+	l := c.l.With("func", "core/ContractABI", "contract", contract.Hex())
+	rawABI, err := c.s.GetContractABI(contract)
+	if err != nil {
+		l.Errorw("cannnot get abi from storage", "err", err)
+	}
+	if len(rawABI) == 0 {
+		rawABI, err = c.getContractABIFromEtherscan(contract, network)
 		if err != nil {
-			l.Errorw("cannnot get abi from storage", "err", err)
+			return "", fmt.Errorf("cannot get contract ABI, err: %w", err)
 		}
-		if len(rawABI) == 0 {
-			rawABI, err = c.getContractABIFromEtherscan(contract, network)
-			if err != nil {
-				return nil, fmt.Errorf("cannot get contract ABI, err: %s", err.Error())
-			}
+	}
+	return rawABI, nil
+}
+
+// ContractMethods returns view methods of contract, given abi is stored as written by rememberBy if it is not empty
+func (c *Core) ContractMethods(contract ethereum.Address, contractABI, rememberBy, network string) ([]common.Method, error) {
+	l := c.l.With("func", "core/ContractMethods", "contract", contract.Hex())
+	contractABI, source := ABIFromArtifact(contractABI)
+	lookedUp := len(contractABI) == 0
+	if lookedUp {
+		rawABI, err := c.ContractABI(contract, network)
+		if err != nil {
+			return nil, err
 		}
 		contractABI = rawABI
 	} else {
 		if err := c.verifyContract(contract); err != nil {
-			return nil, fmt.Errorf("cannot verify contract, err: %s", err.Error())
+			return nil, wrapError(err, "cannot verify contract, err: %s", err.Error())
 		}
 	}
 	cABI, err := abi.JSON(strings.NewReader(contractABI))
 	if err != nil {
-		return nil, fmt.Errorf("cannot read abi, err: %s", err.Error())
+		return nil, argumentError("abi", "cannot read abi, err: %s", err.Error())
 	}
-	if rememberABI {
-		if err := c.s.StoreContractABI(contract, contractABI); err != nil {
+	if rememberBy != "" {
+		if lookedUp {
+			if source, err = c.abiSource(contract, contractABI); err != nil {
+				return nil, err
+			}
+		}
+		if err := c.s.StoreContractABI(contract, contractABI, source, rememberBy); err != nil {
 			l.Errorw("cannot store contract abi", "err", err)
 		}
 	}
+	// docs are optional, contracts with a given abi may not be verified
+	docs := make(map[string]string)
+	if source, err := c.ContractSource(contract, network); err != nil {
+		l.Debugw("no contract source for docs", "err", err)
+	} else {
+		docs = source.Docs
+	}
 	var result []common.Method
 	for name, detail := range cABI.Methods {
 		if !detail.IsConstant() {
@@ -131,6 +191,7 @@ func (c *Core) ContractMethods(contract ethereum.Address, contractABI string, re
 		result = append(result, common.Method{
 			Name:      name,
 			Arguments: args,
+			Doc:       docs[detail.RawName],
 		})
 	}
 	return result, nil
@@ -145,6 +206,60 @@ func (c *Core) CallContract(
 	customNode string) (interface{}, error) {
 
 	l := c.l.With("func", "core/CallContract", "contract", contract.Hex())
+	bn, err := ParseBlockNumber(blockNumber)
+	if err != nil {
+		l.Errorw("cannot handle block number", "err", err)
+		return nil, err
+	}
+	eclient, err := c.nodeClient(customNode)
+	if err != nil {
+		return nil, err
+	}
+	if eclient != c.ecli {
+		defer eclient.Close()
+	}
+	return c.callContract(eclient, contract, contractABI, methodName, bn, params)
+}
+
+// callContract packs params, calls method on given client at block bn (latest if nil) and unpacks the result
+func (c *Core) callContract(
+	eclient *ethclient.Client,
+	contract ethereum.Address,
+	contractABI, methodName string,
+	bn *big.Int,
+	params map[string]interface{}) (interface{}, error) {
+
+	l := c.l.With("func", "core/callContract", "contract", contract.Hex())
+	pc, err := c.prepareCall(contract, contractABI, methodName, params)
+	if err != nil {
+		return nil, err
+	}
+	caller := cc.NewContractCaller(pc.cABI, eclient, contract)
+	result, err := caller.CallWithInput(&bind.CallOpts{
+		BlockNumber: bn,
+	}, methodName, pc.data)
+	if err != nil {
+		l.Errorw("cannot get contract data", "err", err)
+		return nil, upstreamError(err, "cannot get data from contract, err=%s", err)
+	}
+	return result, nil
+}
+
+// preparedCall is a packed call of a contract method
+type preparedCall struct {
+	contract ethereum.Address
+	cABI     abi.ABI
+	method   string
+	data     []byte
+}
+
+// prepareCall parses abi (stored abi if empty) and packs params of method
+func (c *Core) prepareCall(
+	contract ethereum.Address,
+	contractABI, methodName string,
+	params map[string]interface{}) (*preparedCall, error) {
+
+	l := c.l.With("func", "core/prepareCall", "contract", contract.Hex())
 	if contractABI == "" {
 		storedABI, err := c.s.GetContractABI(contract)
 		if err != nil {
@@ -155,12 +270,12 @@ func (c *Core) CallContract(
 	cABI, err := abi.JSON(strings.NewReader(contractABI))
 	if err != nil {
 		l.Errorw("cannot read abi", "err", err)
-		return nil, fmt.Errorf("cannot read abi, err: %s", err.Error())
+		return nil, argumentError("abi", "cannot read abi, err: %s", err.Error())
 	}
 	method, ok := cABI.Methods[methodName]
 	if !ok {
 		l.Errorw("method is not available in this contract", "method", methodName)
-		return nil, fmt.Errorf("method is not available in this contract, method = %s", methodName)
+		return nil, argumentError("method", "method is not available in this contract, method = %s", methodName)
 	}
 
 	var input []interface{}
@@ -173,7 +288,7 @@ func (c *Core) CallContract(
 			ps, ok = p.(string)
 			if !ok {
 				l.Errorw("wrong data type", "method", methodName, "arg name", arg.Name)
-				return nil, fmt.Errorf("wrong data type, method = %s, arg name = %s", methodName, arg.Name)
+				return nil, argumentError(arg.Name, "wrong data type, method = %s, arg name = %s", methodName, arg.Name)
 			}
 		}
 		i, err := handleData(arg, ps)
@@ -183,44 +298,50 @@ func (c *Core) CallContract(
 		}
 		input = append(input, i)
 	}
-	var (
-		bn   *big.Int
-		errB error
-	)
-	if blockNumber != "" {
-		if strings.Contains(blockNumber, "0x") {
-			bn, errB = hexutil.DecodeBig(blockNumber)
-			if errB != nil {
-				l.Errorw("cannot handle block number", "err", errB)
-				return nil, errB
-			}
-		} else {
-			var ok bool
-			bn, ok = big.NewInt(0).SetString(blockNumber, 10)
-			if !ok {
-				return nil, fmt.Errorf("wrong data type block number, input=%s", blockNumber)
-			}
-		}
+	data, err := cABI.Pack(methodName, input...)
+	if err != nil {
+		return nil, validationError("cannot pack params, method = %s, err: %s", methodName, err.Error())
 	}
-	var eclient *ethclient.Client
-	if customNode == "" {
-		eclient = c.ecli
-	} else {
-		var eErr error
-		eclient, eErr = ethclient.Dial(customNode)
-		if eErr != nil {
-			return nil, fmt.Errorf("cannot connect to given node, node=%s", customNode)
+	return &preparedCall{
+		contract: contract,
+		cABI:     cABI,
+		method:   methodName,
+		data:     data,
+	}, nil
+}
+
+// ParseBlockNumber parses decimal or 0x-prefixed hex block number, empty input means latest (nil)
+func ParseBlockNumber(blockNumber string) (*big.Int, error) {
+	if blockNumber == "" {
+		return nil, nil
+	}
+	if strings.Contains(blockNumber, "0x") {
+		bn, err := hexutil.DecodeBig(blockNumber)
+		if err != nil {
+			return nil, argumentError("blockNumber", "wrong data type block number, input=%s, err: %s", blockNumber, err)
 		}
+		return bn, nil
 	}
-	caller := cc.NewContractCaller(cABI, eclient, contract)
-	result, err := caller.Call(&bind.CallOpts{
-		BlockNumber: bn,
-	}, methodName, input...)
-	if err != nil {
-		l.Errorw("cannot get contract data", "err", err)
-		return nil, fmt.Errorf("cannot get data from contract, err=%s", err)
+	bn, ok := big.NewInt(0).SetString(blockNumber, 10)
+	if !ok {
+		return nil, argumentError("blockNumber", "wrong data type block number, input=%s", blockNumber)
 	}
-	return result, nil
+	return bn, nil
+}
+
+// nodeClient returns client of customNode, or the default client if customNode is empty
+func (c *Core) nodeClient(customNode string) (*ethclient.Client, error) {
+	if customNode == "" {
+		return c.ecli, nil
+	}
+	return c.nodes.dial("customNode", customNode)
+}
+
+// wrongDataType is a validation error of a param which cannot be converted to its abi type
+func wrongDataType(name, typeName, value string) *Error {
+	e := argumentError(name, "wrong data type, arg=%s, expected type=%s, actual value=%s", name, typeName, value)
+	e.Details["expectedType"] = typeName
+	return e
 }
 
 func handleData(arg abi.Argument, ps string) (interface{}, error) {
@@ -229,8 +350,7 @@ func handleData(arg abi.Argument, ps string) (interface{}, error) {
 	case "uint256", "int256", "uint128", "int128":
 		b, ok := big.NewInt(0).SetString(ps, 10)
 		if !ok {
-			return nil, fmt.Errorf("wrong data type, arg=%s, expected type=%s, actual value=%s",
-				arg.Name, typeName, ps)
+			return nil, wrongDataType(arg.Name, typeName, ps)
 		}
 		return b, nil
 	case "uint256[]", "int256[]", "uint128[]", "int128[]":
@@ -240,8 +360,7 @@ func handleData(arg abi.Argument, ps string) (interface{}, error) {
 		for _, n := range nums {
 			b, ok := big.NewInt(0).SetString(n, 10)
 			if !ok {
-				return nil, fmt.Errorf("wrong data type, arg=%s, expected type=%s, actual value=%s",
-					arg.Name, typeName, ps)
+				return nil, wrongDataType(arg.Name, typeName, ps)
 			}
 			bs = append(bs, b)
 		}
@@ -252,16 +371,14 @@ func handleData(arg abi.Argument, ps string) (interface{}, error) {
 		var as []ethereum.Address
 		for _, a := range addresses {
 			if !ethereum.IsHexAddress(a) {
-				return nil, fmt.Errorf("wrong data type, arg=%s, expected type=%s, actual value=%s",
-					arg.Name, typeName, ps)
+				return nil, wrongDataType(arg.Name, typeName, ps)
 			}
 			as = append(as, ethereum.HexToAddress(a))
 		}
 		return as, nil
 	case "address":
 		if !ethereum.IsHexAddress(ps) {
-			return nil, fmt.Errorf("wrong data type, arg=%s, expected type=%s, actual value=%s",
-				arg.Name, typeName, ps)
+			return nil, wrongDataType(arg.Name, typeName, ps)
 		}
 		return ethereum.HexToAddress(ps), nil
 	case "bool":
@@ -271,8 +388,7 @@ func handleData(arg abi.Argument, ps string) (interface{}, error) {
 		case "true":
 			b = true
 		default:
-			return nil, fmt.Errorf("wrong data type, arg=%s, expected type=%s, actual value=%s",
-				arg.Name, typeName, ps)
+			return nil, wrongDataType(arg.Name, typeName, ps)
 		}
 		return b, nil
 	case "bool[]":
@@ -286,8 +402,7 @@ func handleData(arg abi.Argument, ps string) (interface{}, error) {
 			case "true":
 				bs = append(bs, true)
 			default:
-				return nil, fmt.Errorf("wrong data type, arg=%s, expected type=%s, actual value=%s",
-					arg.Name, typeName, ps)
+				return nil, wrongDataType(arg.Name, typeName, ps)
 			}
 		}
 		return bs, nil
@@ -295,15 +410,13 @@ func handleData(arg abi.Argument, ps string) (interface{}, error) {
 		if strings.Contains(ps, "0x") {
 			b, err := hexutil.Decode(ps)
 			if err != nil {
-				return nil, fmt.Errorf("wrong data type, arg=%s, expected type=%s, actual value=%s",
-					arg.Name, typeName, ps)
+				return nil, wrongDataType(arg.Name, typeName, ps)
 			}
 			return b, err
 		}
 		return []byte(ps), nil
 	default:
-		return nil, fmt.Errorf("wrong data type, arg=%s, expected type=%s, actual value=%s",
-			arg.Name, typeName, ps)
+		return nil, wrongDataType(arg.Name, typeName, ps)
 	}
 }
 
@@ -312,9 +425,10 @@ func (c *Core) NetworkInfo(node string) (string, error) {
 	if node == "" {
 		return c.network, nil
 	}
-	ecli, err := ethclient.Dial(node)
+	ecli, err := c.nodes.dial("node", node)
 	if err != nil {
 		return "", err
 	}
+	defer ecli.Close()
 	return networkFromNode(ecli)
 }
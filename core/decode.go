@@ -0,0 +1,71 @@
+package core
+
+import (
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	ethereum "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/KyberNetwork/contract-caller/common"
+)
+
+// DecodeData decodes hex data with contractABI. If methodName is empty data is treated as calldata and
+// the method is found by its selector, otherwise data is treated as return data of methodName.
+func DecodeData(contractABI, methodName, data string) (common.DecodedData, error) {
+	cABI, err := abi.JSON(strings.NewReader(contractABI))
+	if err != nil {
+		return common.DecodedData{}, argumentError("abi", "cannot read abi, err: %s", err.Error())
+	}
+	raw, err := hexutil.Decode(data)
+	if err != nil {
+		return common.DecodedData{}, argumentError("data", "data is not valid hex, err: %s", err.Error())
+	}
+	var (
+		method *abi.Method
+		args   abi.Arguments
+	)
+	if methodName == "" {
+		if len(raw) < 4 {
+			return common.DecodedData{}, argumentError("data", "calldata is shorter than a method selector")
+		}
+		if method, err = cABI.MethodById(raw[:4]); err != nil {
+			return common.DecodedData{}, argumentError("data", "%s", err.Error())
+		}
+		args, raw = method.Inputs, raw[4:]
+	} else {
+		m, ok := cABI.Methods[methodName]
+		if !ok {
+			return common.DecodedData{}, argumentError("method", "method is not available in this contract, method = %s", methodName)
+		}
+		method, args = &m, m.Outputs
+	}
+	values, err := args.Unpack(raw)
+	if err != nil {
+		return common.DecodedData{}, argumentError("data", "cannot decode data, method = %s, err: %s", method.Name, err.Error())
+	}
+	result := common.DecodedData{
+		Method:    method.Name,
+		Signature: method.Sig,
+	}
+	for i, arg := range args {
+		result.Arguments = append(result.Arguments, common.DecodedArgument{
+			Name:  arg.Name,
+			Type:  arg.Type.String(),
+			Value: values[i],
+		})
+	}
+	return result, nil
+}
+
+// Decode decodes data like DecodeData, looking up abi of contract if contractABI is empty
+func (c *Core) Decode(contract ethereum.Address, contractABI, network, methodName, data string) (common.DecodedData, error) {
+	if len(contractABI) == 0 {
+		rawABI, err := c.ContractABI(contract, network)
+		if err != nil {
+			return common.DecodedData{}, err
+		}
+		contractABI = rawABI
+	}
+	return DecodeData(contractABI, methodName, data)
+}
@@ -0,0 +1,31 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	ethereum "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+const erc20BalanceOfABI = `[{"constant":true,"inputs":[{"name":"owner","type":"address"}],"name":"balanceOf",` +
+	`"outputs":[{"name":"balance","type":"uint256"}],"stateMutability":"view","type":"function"}]`
+
+func TestDecodeData(t *testing.T) {
+	owner := ethereum.HexToAddress("0xbc5b5c036eb41a1a85af0b4da13d56420e8a0a92")
+	calldata := "0x70a08231000000000000000000000000" + owner.Hex()[2:]
+	decoded, err := DecodeData(erc20BalanceOfABI, "", calldata)
+	require.NoError(t, err)
+	require.Equal(t, "balanceOf", decoded.Method)
+	require.Len(t, decoded.Arguments, 1)
+	require.Equal(t, "owner", decoded.Arguments[0].Name)
+	require.Equal(t, owner, decoded.Arguments[0].Value)
+
+	returnData := "0x00000000000000000000000000000000000000000000000000000000000003e8"
+	decoded, err = DecodeData(erc20BalanceOfABI, "balanceOf", returnData)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(1000), decoded.Arguments[0].Value)
+
+	_, err = DecodeData(erc20BalanceOfABI, "", "0x12345678")
+	require.Error(t, err)
+}
@@ -0,0 +1,83 @@
+package core
+
+import (
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	ethereum "github.com/ethereum/go-ethereum/common"
+
+	"github.com/KyberNetwork/contract-caller/common"
+)
+
+// viewCall calls a zero-argument view method at block bn
+type viewCall func(method string, bn *big.Int) (interface{}, error)
+
+// DiffContractState calls every zero-argument view method of contract at fromBlock and toBlock
+// and reports which values changed.
+func (c *Core) DiffContractState(
+	contract ethereum.Address,
+	contractABI, network string,
+	fromBlock, toBlock string,
+	customNode string) ([]common.StateDiff, error) {
+
+	if len(contractABI) == 0 {
+		rawABI, err := c.ContractABI(contract, network)
+		if err != nil {
+			return nil, err
+		}
+		contractABI = rawABI
+	}
+	cABI, err := abi.JSON(strings.NewReader(contractABI))
+	if err != nil {
+		return nil, argumentError("abi", "cannot read abi, err: %s", err.Error())
+	}
+	from, err := ParseBlockNumber(fromBlock)
+	if err != nil {
+		return nil, err
+	}
+	to, err := ParseBlockNumber(toBlock)
+	if err != nil {
+		return nil, err
+	}
+	eclient, err := c.nodeClient(customNode)
+	if err != nil {
+		return nil, err
+	}
+	if eclient != c.ecli {
+		defer eclient.Close()
+	}
+	return diffViews(cABI, from, to, func(method string, bn *big.Int) (interface{}, error) {
+		return c.callContract(eclient, contract, contractABI, method, bn, nil)
+	}), nil
+}
+
+// diffViews calls zero-argument view methods of cABI at both blocks, sorted by name
+func diffViews(cABI abi.ABI, from, to *big.Int, call viewCall) []common.StateDiff {
+	var names []string
+	for name, m := range cABI.Methods {
+		if m.IsConstant() && len(m.Inputs) == 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var result []common.StateDiff
+	for _, name := range names {
+		d := common.StateDiff{Method: name}
+		fromResult, err := call(name, from)
+		if err != nil {
+			d.FromErr = err.Error()
+		}
+		toResult, err := call(name, to)
+		if err != nil {
+			d.ToErr = err.Error()
+		}
+		d.From, d.To = fromResult, toResult
+		d.Changed = d.FromErr != d.ToErr || !reflect.DeepEqual(fromResult, toResult)
+		result = append(result, d)
+	}
+	return result
+}
@@ -0,0 +1,58 @@
+package core
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffViews(t *testing.T) {
+	cABI, err := abi.JSON(strings.NewReader(`[
+	{"inputs":[],"name":"totalSupply","outputs":[{"type":"uint256"}],"stateMutability":"view","type":"function"},
+	{"inputs":[],"name":"owner","outputs":[{"type":"address"}],"stateMutability":"view","type":"function"},
+	{"inputs":[],"name":"paused","outputs":[{"type":"bool"}],"stateMutability":"view","type":"function"},
+	{"inputs":[],"name":"cap","outputs":[{"type":"uint256"}],"stateMutability":"view","type":"function"},
+	{"inputs":[{"name":"a","type":"address"}],"name":"balanceOf","outputs":[{"type":"uint256"}],"stateMutability":"view","type":"function"},
+	{"inputs":[],"name":"pause","outputs":[],"stateMutability":"nonpayable","type":"function"}]`))
+	require.NoError(t, err)
+	from, to := big.NewInt(100), big.NewInt(200)
+	var called []string
+	diffs := diffViews(cABI, from, to, func(method string, bn *big.Int) (interface{}, error) {
+		called = append(called, method)
+		switch {
+		case method == "totalSupply":
+			return []interface{}{new(big.Int).Mul(bn, big.NewInt(10))}, nil
+		case method == "owner":
+			return []interface{}{"0x01"}, nil
+		case method == "paused" && bn == from:
+			return nil, errors.New("execution reverted")
+		case method == "cap":
+			return nil, errors.New("execution reverted")
+		}
+		return []interface{}{false}, nil
+	})
+	require.ElementsMatch(t, []string{"cap", "cap", "owner", "owner", "paused", "paused", "totalSupply", "totalSupply"}, called)
+	require.Len(t, diffs, 4)
+
+	require.Equal(t, "cap", diffs[0].Method)
+	require.False(t, diffs[0].Changed)
+	require.NotEmpty(t, diffs[0].FromErr)
+	require.NotEmpty(t, diffs[0].ToErr)
+
+	require.Equal(t, "owner", diffs[1].Method)
+	require.False(t, diffs[1].Changed)
+
+	require.Equal(t, "paused", diffs[2].Method)
+	require.True(t, diffs[2].Changed)
+	require.Equal(t, "execution reverted", diffs[2].FromErr)
+	require.Empty(t, diffs[2].ToErr)
+	require.Equal(t, []interface{}{false}, diffs[2].To)
+
+	require.Equal(t, "totalSupply", diffs[3].Method)
+	require.True(t, diffs[3].Changed)
+	require.Equal(t, []interface{}{big.NewInt(2000)}, diffs[3].To)
+}
@@ -0,0 +1,135 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/KyberNetwork/contract-caller/lib/etherscan"
+	libhttp "github.com/KyberNetwork/contract-caller/lib/http"
+)
+
+// ErrorCode is machine readable kind of an error
+type ErrorCode string
+
+const (
+	// CodeValidation is for invalid input such as a bad address, argument or abi
+	CodeValidation ErrorCode = "validation"
+	// CodeNotFound is for missing resources such as a query, an unverified abi or a contract without code
+	CodeNotFound ErrorCode = "not_found"
+	// CodeUpstream is for failures of node or explorer
+	CodeUpstream ErrorCode = "upstream"
+	// CodeRevert is for calls reverted by the contract
+	CodeRevert ErrorCode = "revert"
+	// CodeTimeout is for node or explorer requests running out of time
+	CodeTimeout ErrorCode = "timeout"
+	// CodeInternal is for unexpected errors
+	CodeInternal ErrorCode = "internal"
+	// CodeUnauthorized is for requests without a valid api key
+	CodeUnauthorized ErrorCode = "unauthorized"
+	// CodeForbidden is for requests whose api key role does not allow them
+	CodeForbidden ErrorCode = "forbidden"
+	// CodeRateLimited is for requests over rate limit or quota of their api key
+	CodeRateLimited ErrorCode = "rate_limited"
+)
+
+// revertPrefix is how nodes report reverted calls, followed by ": reason" when there is one
+const revertPrefix = "execution reverted"
+
+// Error is an error of core with a code, details give context such as the offending argument
+type Error struct {
+	Code    ErrorCode
+	Message string
+	Details map[string]interface{}
+	Err     error
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Unwrap returns the cause of e
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func validationError(format string, args ...interface{}) *Error {
+	return &Error{Code: CodeValidation, Message: fmt.Sprintf(format, args...)}
+}
+
+// argumentError is a validation error of an input argument
+func argumentError(argument, format string, args ...interface{}) *Error {
+	e := validationError(format, args...)
+	e.Details = map[string]interface{}{"argument": argument}
+	return e
+}
+
+func notFoundError(format string, args ...interface{}) *Error {
+	return &Error{Code: CodeNotFound, Message: fmt.Sprintf(format, args...)}
+}
+
+// upstreamError is a failure of node or explorer, classified as revert or timeout when cause tells so
+func upstreamError(cause error, format string, args ...interface{}) *Error {
+	e := classify(cause)
+	if e.Code == CodeInternal {
+		e.Code = CodeUpstream
+	}
+	e.Message, e.Err = fmt.Sprintf(format, args...), cause
+	return e
+}
+
+// wrapError prefixes message of err keeping its code and details
+func wrapError(err error, format string, args ...interface{}) *Error {
+	e := AsError(err)
+	return &Error{Code: e.Code, Message: fmt.Sprintf(format, args...), Details: e.Details, Err: err}
+}
+
+// AsError returns err as a core error, errors without a code are classified by their cause
+func AsError(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	e = classify(err)
+	e.Message, e.Err = err.Error(), err
+	return e
+}
+
+func classify(err error) *Error {
+	if err == nil {
+		return &Error{Code: CodeInternal}
+	}
+	var (
+		netErr     net.Error
+		esErr      *etherscan.Error
+		blockedErr *NodeBlockedError
+	)
+	switch {
+	case errors.As(err, &blockedErr):
+		return &Error{Code: CodeValidation, Details: map[string]interface{}{
+			"host": blockedErr.Host, "reason": blockedErr.Reason}}
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return &Error{Code: CodeTimeout}
+	case errors.As(err, &esErr) && esErr.Kind == etherscan.KindNotVerified:
+		return &Error{Code: CodeNotFound}
+	case esErr != nil:
+		return &Error{Code: CodeUpstream, Details: map[string]interface{}{"explorer": string(esErr.Kind)}}
+	case libhttp.StatusCode(err) != 0:
+		return &Error{Code: CodeUpstream, Details: map[string]interface{}{"status": libhttp.StatusCode(err)}}
+	}
+	msg := err.Error()
+	if i := strings.Index(msg, revertPrefix); i >= 0 {
+		e := &Error{Code: CodeRevert}
+		if reason := strings.TrimPrefix(msg[i+len(revertPrefix):], ": "); reason != "" {
+			e.Details = map[string]interface{}{"reason": reason}
+		}
+		return e
+	}
+	if strings.Contains(msg, "abi:") {
+		// output does not match the abi, e.g. method does not exist at this address
+		return &Error{Code: CodeValidation}
+	}
+	return &Error{Code: CodeInternal}
+}
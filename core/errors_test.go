@@ -0,0 +1,48 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/KyberNetwork/contract-caller/lib/etherscan"
+)
+
+func TestAsError(t *testing.T) {
+	e := AsError(errors.New("execution reverted: insufficient balance"))
+	require.Equal(t, CodeRevert, e.Code)
+	require.Equal(t, "insufficient balance", e.Details["reason"])
+
+	e = AsError(fmt.Errorf("cannot get contract ABI, err: %w", &etherscan.Error{Kind: etherscan.KindNotVerified}))
+	require.Equal(t, CodeNotFound, e.Code)
+
+	e = AsError(&etherscan.Error{Kind: etherscan.KindNetwork, Message: "failed to do req",
+		Err: fmt.Errorf("failed to do req: %w", context.DeadlineExceeded)})
+	require.Equal(t, CodeTimeout, e.Code)
+
+	e = AsError(upstreamError(context.DeadlineExceeded, "cannot call"))
+	require.Equal(t, CodeTimeout, e.Code)
+
+	e = AsError(upstreamError(errors.New("connection refused"), "cannot call"))
+	require.Equal(t, CodeUpstream, e.Code)
+	e = AsError(wrapError(e, "cannot verify contract, err: %s", e.Message))
+	require.Equal(t, CodeUpstream, e.Code)
+
+	e = AsError(wrapError(argumentError("owner", "bad owner"), "step s1: bad owner"))
+	require.Equal(t, CodeValidation, e.Code)
+	require.Equal(t, "owner", e.Details["argument"])
+	require.Equal(t, "step s1: bad owner", e.Message)
+
+	require.Equal(t, CodeInternal, AsError(errors.New("boom")).Code)
+	require.Equal(t, CodeUpstream, upstreamError(nil, "unexpected output").Code)
+}
+
+func TestDecodeDataErrors(t *testing.T) {
+	_, err := DecodeData(erc20BalanceOfABI, "", "0xzz")
+	e := AsError(err)
+	require.Equal(t, CodeValidation, e.Code)
+	require.Equal(t, "data", e.Details["argument"])
+}
@@ -0,0 +1,148 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"github.com/KyberNetwork/contract-caller/common"
+)
+
+const (
+	defaultHistoryLimit = 100
+	maxHistoryLimit     = 1000
+)
+
+// CallContractWithHistory calls contract like CallContract, pinning the block first when none is given,
+// and records the call, its result or error and latency to history.
+func (c *Core) CallContractWithHistory(
+	contract ethereum.Address,
+	contractABI, methodName string,
+	blockNumber string,
+	params map[string]interface{},
+	customNode string) (interface{}, error) {
+
+	l := c.l.With("func", "core/CallContractWithHistory", "contract", contract.Hex())
+	bn, err := ParseBlockNumber(blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	eclient, err := c.nodeClient(customNode)
+	if err != nil {
+		return nil, err
+	}
+	if eclient != c.ecli {
+		defer eclient.Close()
+	}
+	if contractABI == "" {
+		if contractABI, err = c.s.GetContractABI(contract); err != nil {
+			l.Errorw("cannnot get abi from storage", "err", err)
+		}
+	}
+	h := common.CallHistory{
+		Contract:    contract.Hex(),
+		ABI:         contractABI,
+		Method:      methodName,
+		Params:      params,
+		BlockNumber: blockNumber,
+		Node:        customNode,
+		CreatedAt:   time.Now().Unix(),
+	}
+	result, err := c.executeRecorded(eclient, &h, bn)
+	if _, sErr := c.s.StoreCallHistory(h); sErr != nil {
+		l.Errorw("cannot store call history", "err", sErr)
+	}
+	return result, err
+}
+
+// executeRecorded calls contract as described by h at block bn (latest block if nil)
+// and fills resolved block, result, error and latency of h.
+func (c *Core) executeRecorded(eclient *ethclient.Client, h *common.CallHistory, bn *big.Int) (interface{}, error) {
+	start := time.Now()
+	defer func() {
+		h.LatencyMs = time.Since(start).Milliseconds()
+	}()
+	if bn == nil {
+		head, err := eclient.HeaderByNumber(context.Background(), nil)
+		if err != nil {
+			e := upstreamError(err, "cannot get latest block, err=%s", err)
+			h.Err = e.Message
+			return nil, e
+		}
+		bn = head.Number
+	}
+	h.ResolvedBlock = bn.Uint64()
+	result, err := c.callContract(eclient, ethereum.HexToAddress(h.Contract), h.ABI, h.Method, bn, h.Params)
+	if err != nil {
+		h.Err = err.Error()
+		return nil, err
+	}
+	if h.Result, err = json.Marshal(result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// CallHistory returns recorded calls matching filter
+func (c *Core) CallHistory(f common.HistoryFilter) ([]common.CallHistory, error) {
+	if f.Contract != "" {
+		if !ethereum.IsHexAddress(f.Contract) {
+			return nil, argumentError("contract", "contract is not a valid ethereum address")
+		}
+		f.Contract = ethereum.HexToAddress(f.Contract).Hex()
+	}
+	if f.Limit <= 0 {
+		f.Limit = defaultHistoryLimit
+	}
+	if f.Limit > maxHistoryLimit {
+		f.Limit = maxHistoryLimit
+	}
+	return c.s.SearchCallHistory(f)
+}
+
+// RecordedCall returns recorded call of given id
+func (c *Core) RecordedCall(id int64) (common.CallHistory, error) {
+	h, err := c.s.GetCallHistory(id)
+	if err != nil {
+		return common.CallHistory{}, err
+	}
+	if h == nil {
+		return common.CallHistory{}, notFoundError("call history not found, id=%d", id)
+	}
+	return *h, nil
+}
+
+// ReplayCall re-executes recorded call of given id at its original block, or at latest block if atLatest,
+// using the node of the original call, and reports whether the result differs.
+func (c *Core) ReplayCall(id int64, atLatest bool) (common.Replay, error) {
+	h, err := c.RecordedCall(id)
+	if err != nil {
+		return common.Replay{}, err
+	}
+	eclient, err := c.nodeClient(h.Node)
+	if err != nil {
+		return common.Replay{}, err
+	}
+	if eclient != c.ecli {
+		defer eclient.Close()
+	}
+	var bn *big.Int
+	if !atLatest && h.ResolvedBlock != 0 {
+		bn = new(big.Int).SetUint64(h.ResolvedBlock)
+	}
+	replayed := h
+	replayed.Result, replayed.Err = nil, ""
+	_, _ = c.executeRecorded(eclient, &replayed, bn)
+	return common.Replay{
+		Original:    h,
+		BlockNumber: replayed.ResolvedBlock,
+		Result:      replayed.Result,
+		Err:         replayed.Err,
+		Changed:     h.Err != replayed.Err || !bytes.Equal(h.Result, replayed.Result),
+	}, nil
+}
@@ -0,0 +1,30 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/KyberNetwork/contract-caller/common"
+)
+
+func TestExecuteRecordedNodeFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+	eclient, err := ethclient.Dial(srv.URL)
+	require.NoError(t, err)
+	defer eclient.Close()
+
+	c := &Core{l: zap.S()}
+	h := common.CallHistory{Contract: "0x0000000000000000000000000000000000000001", Method: "owner"}
+	_, err = c.executeRecorded(eclient, &h, nil)
+	require.Error(t, err)
+	require.Equal(t, CodeUpstream, AsError(err).Code)
+	require.Contains(t, h.Err, "cannot get latest block")
+}
@@ -0,0 +1,350 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethereum "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"github.com/KyberNetwork/contract-caller/common"
+	cc "github.com/KyberNetwork/contract-caller/lib/contract-caller"
+)
+
+var (
+	erc165InterfaceID        = [4]byte{0x01, 0xff, 0xc9, 0xa7}
+	invalidInterfaceID       = [4]byte{0xff, 0xff, 0xff, 0xff}
+	erc721InterfaceID        = [4]byte{0x80, 0xac, 0x58, 0xcd}
+	erc1155InterfaceID       = [4]byte{0xd9, 0xb6, 0x7a, 0x26}
+	accessControlInterfaceID = [4]byte{0x79, 0x65, 0xdb, 0x0b}
+)
+
+// standardInterface is a well-known interface, functions are written as
+// name(type name, ...) [view|pure|payable] [returns (type name, ...)]
+type standardInterface struct {
+	name        string
+	interfaceID *[4]byte
+	functions   []string
+}
+
+var erc20Functions = []string{
+	"name() view returns (string)",
+	"symbol() view returns (string)",
+	"decimals() view returns (uint8)",
+	"totalSupply() view returns (uint256)",
+	"balanceOf(address owner) view returns (uint256)",
+	"allowance(address owner, address spender) view returns (uint256)",
+	"transfer(address to, uint256 value) returns (bool)",
+	"approve(address spender, uint256 value) returns (bool)",
+	"transferFrom(address from, address to, uint256 value) returns (bool)",
+}
+
+// standardInterfaces is the library of well-known interfaces, usable as abi by name
+var standardInterfaces = []standardInterface{
+	{
+		name:      "erc20",
+		functions: erc20Functions,
+	},
+	{
+		name:        "erc721",
+		interfaceID: &erc721InterfaceID,
+		functions: []string{
+			"balanceOf(address owner) view returns (uint256)",
+			"ownerOf(uint256 tokenId) view returns (address)",
+			"getApproved(uint256 tokenId) view returns (address)",
+			"isApprovedForAll(address owner, address operator) view returns (bool)",
+			"supportsInterface(bytes4 interfaceId) view returns (bool)",
+			"approve(address to, uint256 tokenId)",
+			"setApprovalForAll(address operator, bool approved)",
+			"transferFrom(address from, address to, uint256 tokenId)",
+			"safeTransferFrom(address from, address to, uint256 tokenId)",
+			"safeTransferFrom(address from, address to, uint256 tokenId, bytes data)",
+		},
+	},
+	{
+		name:        "erc1155",
+		interfaceID: &erc1155InterfaceID,
+		functions: []string{
+			"balanceOf(address account, uint256 id) view returns (uint256)",
+			"balanceOfBatch(address[] accounts, uint256[] ids) view returns (uint256[])",
+			"isApprovedForAll(address account, address operator) view returns (bool)",
+			"supportsInterface(bytes4 interfaceId) view returns (bool)",
+			"setApprovalForAll(address operator, bool approved)",
+			"safeTransferFrom(address from, address to, uint256 id, uint256 amount, bytes data)",
+			"safeBatchTransferFrom(address from, address to, uint256[] ids, uint256[] amounts, bytes data)",
+		},
+	},
+	{
+		name: "erc4626",
+		functions: append(append([]string{}, erc20Functions...),
+			"asset() view returns (address assetTokenAddress)",
+			"totalAssets() view returns (uint256 totalManagedAssets)",
+			"convertToShares(uint256 assets) view returns (uint256 shares)",
+			"convertToAssets(uint256 shares) view returns (uint256 assets)",
+			"maxDeposit(address receiver) view returns (uint256 maxAssets)",
+			"previewDeposit(uint256 assets) view returns (uint256 shares)",
+			"maxMint(address receiver) view returns (uint256 maxShares)",
+			"previewMint(uint256 shares) view returns (uint256 assets)",
+			"maxWithdraw(address owner) view returns (uint256 maxAssets)",
+			"previewWithdraw(uint256 assets) view returns (uint256 shares)",
+			"maxRedeem(address owner) view returns (uint256 maxShares)",
+			"previewRedeem(uint256 shares) view returns (uint256 assets)",
+			"deposit(uint256 assets, address receiver) returns (uint256 shares)",
+			"mint(uint256 shares, address receiver) returns (uint256 assets)",
+			"withdraw(uint256 assets, address receiver, address owner) returns (uint256 shares)",
+			"redeem(uint256 shares, address receiver, address owner) returns (uint256 assets)",
+		),
+	},
+	{
+		name: "chainlink-aggregator",
+		functions: []string{
+			"decimals() view returns (uint8)",
+			"description() view returns (string)",
+			"version() view returns (uint256)",
+			"getRoundData(uint80 roundId) view returns (uint80 roundId, int256 answer, uint256 startedAt, uint256 updatedAt, uint80 answeredInRound)",
+			"latestRoundData() view returns (uint80 roundId, int256 answer, uint256 startedAt, uint256 updatedAt, uint80 answeredInRound)",
+		},
+	},
+	{
+		name: "uniswap-v2-pair",
+		functions: append(append([]string{}, erc20Functions...),
+			"factory() view returns (address)",
+			"token0() view returns (address)",
+			"token1() view returns (address)",
+			"getReserves() view returns (uint112 reserve0, uint112 reserve1, uint32 blockTimestampLast)",
+			"price0CumulativeLast() view returns (uint256)",
+			"price1CumulativeLast() view returns (uint256)",
+			"kLast() view returns (uint256)",
+			"mint(address to) returns (uint256 liquidity)",
+			"burn(address to) returns (uint256 amount0, uint256 amount1)",
+			"swap(uint256 amount0Out, uint256 amount1Out, address to, bytes data)",
+			"skim(address to)",
+			"sync()",
+		),
+	},
+	{
+		name: "gnosis-safe",
+		functions: []string{
+			"VERSION() view returns (string)",
+			"getOwners() view returns (address[])",
+			"getThreshold() view returns (uint256)",
+			"isOwner(address owner) view returns (bool)",
+			"nonce() view returns (uint256)",
+			"isModuleEnabled(address module) view returns (bool)",
+			"getTransactionHash(address to, uint256 value, bytes data, uint8 operation, uint256 safeTxGas, uint256 baseGas, uint256 gasPrice, address gasToken, address refundReceiver, uint256 _nonce) view returns (bytes32)",
+			"execTransaction(address to, uint256 value, bytes data, uint8 operation, uint256 safeTxGas, uint256 baseGas, uint256 gasPrice, address gasToken, address refundReceiver, bytes signatures) payable returns (bool success)",
+		},
+	},
+	{
+		name: "ownable",
+		functions: []string{
+			"owner() view returns (address)",
+			"transferOwnership(address newOwner)",
+			"renounceOwnership()",
+		},
+	},
+	{
+		name:        "access-control",
+		interfaceID: &accessControlInterfaceID,
+		functions: []string{
+			"hasRole(bytes32 role, address account) view returns (bool)",
+			"getRoleAdmin(bytes32 role) view returns (bytes32)",
+			"grantRole(bytes32 role, address account)",
+			"revokeRole(bytes32 role, address account)",
+			"renounceRole(bytes32 role, address account)",
+		},
+	},
+}
+
+// abiParam is an input or output of an abi entry, indexed and components are only read from abis
+type abiParam struct {
+	Name       string     `json:"name"`
+	Type       string     `json:"type"`
+	Indexed    bool       `json:"indexed,omitempty"`
+	Components []abiParam `json:"components,omitempty"`
+}
+
+type abiFunction struct {
+	Type            string     `json:"type"`
+	Name            string     `json:"name"`
+	Inputs          []abiParam `json:"inputs"`
+	Outputs         []abiParam `json:"outputs"`
+	StateMutability string     `json:"stateMutability"`
+	Constant        bool       `json:"constant"`
+	Payable         bool       `json:"payable"`
+}
+
+// parseParams parses "type name, type name" into abi params
+func parseParams(s string) []abiParam {
+	params := []abiParam{}
+	if strings.TrimSpace(s) == "" {
+		return params
+	}
+	for _, p := range strings.Split(s, ",") {
+		fields := strings.Fields(p)
+		param := abiParam{Type: fields[0]}
+		if len(fields) > 1 {
+			param.Name = fields[1]
+		}
+		params = append(params, param)
+	}
+	return params
+}
+
+// functionsToABI converts function signatures of a standard interface to abi json
+func functionsToABI(functions []string) (string, error) {
+	var entries []abiFunction
+	for _, f := range functions {
+		open, close := strings.Index(f, "("), strings.Index(f, ")")
+		if open <= 0 || close < open {
+			return "", fmt.Errorf("invalid function signature, signature=%s", f)
+		}
+		fn := abiFunction{
+			Type:            "function",
+			Name:            f[:open],
+			Inputs:          parseParams(f[open+1 : close]),
+			Outputs:         []abiParam{},
+			StateMutability: "nonpayable",
+		}
+		rest := strings.TrimSpace(f[close+1:])
+		for _, m := range []string{"view", "pure", "payable"} {
+			if strings.HasPrefix(rest, m) {
+				fn.StateMutability = m
+				rest = strings.TrimSpace(strings.TrimPrefix(rest, m))
+			}
+		}
+		if strings.HasPrefix(rest, "returns") {
+			rest = strings.TrimSpace(strings.TrimPrefix(rest, "returns"))
+			fn.Outputs = parseParams(strings.TrimSuffix(strings.TrimPrefix(rest, "("), ")"))
+		}
+		fn.Constant = fn.StateMutability == "view" || fn.StateMutability == "pure"
+		fn.Payable = fn.StateMutability == "payable"
+		entries = append(entries, fn)
+	}
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func findStandardInterface(name string) (standardInterface, bool) {
+	for _, i := range standardInterfaces {
+		if i.name == strings.ToLower(name) {
+			return i, true
+		}
+	}
+	return standardInterface{}, false
+}
+
+// StandardInterfaces returns names of interfaces of the standard abi library
+func StandardInterfaces() []string {
+	names := make([]string, 0, len(standardInterfaces))
+	for _, i := range standardInterfaces {
+		names = append(names, i.name)
+	}
+	return names
+}
+
+// StandardABI returns abi json of a standard interface by name, e.g. erc20
+func StandardABI(name string) (string, error) {
+	i, ok := findStandardInterface(name)
+	if !ok {
+		return "", argumentError("interface", "unknown interface, interface=%s, available=%s", name,
+			strings.Join(StandardInterfaces(), ","))
+	}
+	return functionsToABI(i.functions)
+}
+
+// hasSelector checks whether code pushes selector on the stack (PUSH4 selector), which is how solidity
+// and vyper dispatchers compare the call selector
+func hasSelector(code []byte, selector []byte) bool {
+	for i := 0; i+4 < len(code); i++ {
+		if code[i] == 0x63 && code[i+1] == selector[0] && code[i+2] == selector[1] &&
+			code[i+3] == selector[2] && code[i+4] == selector[3] {
+			return true
+		}
+	}
+	return false
+}
+
+// DetectInterfaces reports which standard interfaces contract implements. An interface is implemented when
+// the contract supports its ERC-165 id, when all its function selectors are in the bytecode, or, for proxies
+// whose bytecode does not hold the selectors, when all its argument-less view functions can be called.
+func (c *Core) DetectInterfaces(contract ethereum.Address, blockNumber, customNode string) ([]common.InterfaceMatch, error) {
+	bn, err := ParseBlockNumber(blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	eclient, err := c.nodeClient(customNode)
+	if err != nil {
+		return nil, err
+	}
+	if eclient != c.ecli {
+		defer eclient.Close()
+	}
+	code, err := eclient.CodeAt(context.Background(), contract, bn)
+	if err != nil {
+		return nil, upstreamError(err, "cannot get code of contract, err=%s", err)
+	}
+	if len(code) == 0 {
+		return nil, notFoundError("no code at given contract")
+	}
+	t := &tokenCaller{eclient: eclient, contract: contract, bn: bn}
+	supportsERC165 := t.supportsInterface(erc165InterfaceID) && !t.supportsInterface(invalidInterfaceID)
+
+	var result []common.InterfaceMatch
+	for _, si := range standardInterfaces {
+		rawABI, err := functionsToABI(si.functions)
+		if err != nil {
+			return nil, err
+		}
+		cABI, err := abi.JSON(strings.NewReader(rawABI))
+		if err != nil {
+			return nil, err
+		}
+		m := common.InterfaceMatch{Name: si.name, TotalSelectors: len(cABI.Methods)}
+		for _, method := range cABI.Methods {
+			if hasSelector(code, method.ID) {
+				m.MatchedSelectors++
+			}
+		}
+		switch {
+		case supportsERC165 && si.interfaceID != nil && t.supportsInterface(*si.interfaceID):
+			m.Detection = common.DetectionERC165
+		case m.MatchedSelectors == m.TotalSelectors:
+			m.Detection = common.DetectionBytecode
+		case m.MatchedSelectors == 0 && probeViews(eclient, contract, bn, cABI):
+			m.Detection = common.DetectionProbe
+		default:
+			continue
+		}
+		result = append(result, m)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+// probeViews checks that every argument-less view function of cABI can be called and decoded
+func probeViews(eclient *ethclient.Client, contract ethereum.Address, bn *big.Int, cABI abi.ABI) bool {
+	caller := cc.NewContractCaller(cABI, eclient, contract)
+	probed := 0
+	for name, method := range cABI.Methods {
+		if !method.IsConstant() || len(method.Inputs) != 0 {
+			continue
+		}
+		if _, err := caller.Call(&bind.CallOpts{BlockNumber: bn}, name); err != nil {
+			return false
+		}
+		probed++
+	}
+	return probed != 0
+}
@@ -0,0 +1,45 @@
+package core
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStandardABI(t *testing.T) {
+	for _, name := range StandardInterfaces() {
+		raw, err := StandardABI(name)
+		require.NoError(t, err)
+		_, err = abi.JSON(strings.NewReader(raw))
+		require.NoError(t, err, name)
+	}
+	raw, err := StandardABI("ERC20")
+	require.NoError(t, err)
+	cABI, err := abi.JSON(strings.NewReader(raw))
+	require.NoError(t, err)
+	require.Equal(t, "0x70a08231", hexutil.Encode(cABI.Methods["balanceOf"].ID))
+	require.True(t, cABI.Methods["balanceOf"].IsConstant())
+	require.False(t, cABI.Methods["transfer"].IsConstant())
+
+	raw, err = StandardABI("uniswap-v2-pair")
+	require.NoError(t, err)
+	cABI, err = abi.JSON(strings.NewReader(raw))
+	require.NoError(t, err)
+	require.Len(t, cABI.Methods["getReserves"].Outputs, 3)
+	require.Equal(t, "blockTimestampLast", cABI.Methods["getReserves"].Outputs[2].Name)
+
+	_, err = StandardABI("erc9999")
+	require.Error(t, err)
+}
+
+func TestHasSelector(t *testing.T) {
+	// PUSH4 0x70a08231 EQ
+	code := hexutil.MustDecode("0x806370a0823114")
+	require.True(t, hasSelector(code, hexutil.MustDecode("0x70a08231")))
+	require.False(t, hasSelector(code, hexutil.MustDecode("0xa9059cbb")))
+	// selector bytes without PUSH4
+	require.False(t, hasSelector(hexutil.MustDecode("0x6070a0823114"), hexutil.MustDecode("0x70a08231")))
+}
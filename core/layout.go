@@ -0,0 +1,491 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	ethereum "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/KyberNetwork/contract-caller/common"
+)
+
+// maxStorageBytesLength limits length of string and bytes variables read from storage
+const maxStorageBytesLength = 64 * 1024
+
+// storageLayout is storage layout output of solc (storageLayout of standard json output)
+type storageLayout struct {
+	Storage []storageEntry         `json:"storage"`
+	Types   map[string]storageType `json:"types"`
+}
+
+type storageEntry struct {
+	Label  string `json:"label"`
+	Offset int    `json:"offset"`
+	Slot   string `json:"slot"`
+	Type   string `json:"type"`
+}
+
+type storageType struct {
+	Encoding      string         `json:"encoding"`
+	Label         string         `json:"label"`
+	NumberOfBytes string         `json:"numberOfBytes"`
+	Key           string         `json:"key"`
+	Value         string         `json:"value"`
+	Base          string         `json:"base"`
+	Members       []storageEntry `json:"members"`
+}
+
+// storageRef is location of a resolved variable
+type storageRef struct {
+	slot   *big.Int
+	offset int
+	typ    storageType
+}
+
+// parseStorageLayout reads a storage layout, either as is or from a compiler artifact holding it
+func parseStorageLayout(raw string) (storageLayout, error) {
+	var artifact struct {
+		StorageLayout *storageLayout `json:"storageLayout"`
+	}
+	if err := json.Unmarshal([]byte(raw), &artifact); err != nil {
+		return storageLayout{}, argumentError("layout", "cannot read storage layout, err=%s", err)
+	}
+	if artifact.StorageLayout != nil {
+		return *artifact.StorageLayout, nil
+	}
+	var layout storageLayout
+	if err := json.Unmarshal([]byte(raw), &layout); err != nil {
+		return storageLayout{}, argumentError("layout", "cannot read storage layout, err=%s", err)
+	}
+	if len(layout.Storage) == 0 {
+		return storageLayout{}, argumentError("layout", "storage layout has no variables")
+	}
+	return layout, nil
+}
+
+// splitVariablePath splits a path like balances[0x..].amount into balances, [0x..] and .amount
+func splitVariablePath(path string) ([]string, error) {
+	var parts []string
+	start := 0
+	for i := 0; i < len(path); i++ {
+		switch path[i] {
+		case '[':
+			end := strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated [ in variable %s", path)
+			}
+			if i > start {
+				parts = append(parts, path[start:i])
+			}
+			parts = append(parts, path[i:i+end+1])
+			i += end
+			start = i + 1
+		case '.':
+			if i > start {
+				parts = append(parts, path[start:i])
+			}
+			start = i
+		}
+	}
+	if start < len(path) {
+		parts = append(parts, path[start:])
+	}
+	if len(parts) == 0 || strings.HasPrefix(parts[0], "[") || strings.HasPrefix(parts[0], ".") {
+		return nil, fmt.Errorf("variable must start with a name, variable=%s", path)
+	}
+	return parts, nil
+}
+
+func (l storageLayout) typeOf(id string) (storageType, error) {
+	t, ok := l.Types[id]
+	if !ok {
+		return storageType{}, fmt.Errorf("type %s not found in storage layout", id)
+	}
+	return t, nil
+}
+
+func (t storageType) size() int {
+	n, _ := strconv.Atoi(t.NumberOfBytes)
+	return n
+}
+
+// length returns declared length of a static array type, from its label such as uint256[3]
+func (t storageType) length() (*big.Int, bool) {
+	i := strings.LastIndex(t.Label, "[")
+	if i < 0 || !strings.HasSuffix(t.Label, "]") {
+		return nil, false
+	}
+	return new(big.Int).SetString(t.Label[i+1:len(t.Label)-1], 10)
+}
+
+func parseSlot(slot string) (*big.Int, error) {
+	n, ok := new(big.Int).SetString(slot, 0)
+	if !ok || n.Sign() < 0 || n.BitLen() > 256 {
+		return nil, argumentError("slots", "invalid slot, slot=%s", slot)
+	}
+	return n, nil
+}
+
+func slotHash(slot *big.Int) *big.Int {
+	return new(big.Int).SetBytes(crypto.Keccak256(math.U256Bytes(new(big.Int).Set(slot))))
+}
+
+// resolve finds slot and offset of a variable path
+func (l storageLayout) resolve(path string) (storageRef, error) {
+	parts, err := splitVariablePath(path)
+	if err != nil {
+		return storageRef{}, err
+	}
+	var ref storageRef
+	found := false
+	for _, e := range l.Storage {
+		if e.Label != parts[0] {
+			continue
+		}
+		if ref.slot, err = parseSlot(e.Slot); err != nil {
+			return storageRef{}, err
+		}
+		if ref.typ, err = l.typeOf(e.Type); err != nil {
+			return storageRef{}, err
+		}
+		ref.offset, found = e.Offset, true
+		break
+	}
+	if !found {
+		return storageRef{}, fmt.Errorf("variable %s not found in storage layout", parts[0])
+	}
+	for _, part := range parts[1:] {
+		if strings.HasPrefix(part, ".") {
+			ref, err = l.member(ref, part[1:])
+		} else {
+			ref, err = l.index(ref, strings.TrimSpace(part[1:len(part)-1]))
+		}
+		if err != nil {
+			return storageRef{}, err
+		}
+	}
+	return ref, nil
+}
+
+func (l storageLayout) member(ref storageRef, name string) (storageRef, error) {
+	if len(ref.typ.Members) == 0 {
+		return storageRef{}, fmt.Errorf("%s is not a struct", ref.typ.Label)
+	}
+	for _, m := range ref.typ.Members {
+		if m.Label != name {
+			continue
+		}
+		slot, err := parseSlot(m.Slot)
+		if err != nil {
+			return storageRef{}, err
+		}
+		typ, err := l.typeOf(m.Type)
+		if err != nil {
+			return storageRef{}, err
+		}
+		return storageRef{slot: new(big.Int).Add(ref.slot, slot), offset: m.Offset, typ: typ}, nil
+	}
+	return storageRef{}, fmt.Errorf("%s has no member %s", ref.typ.Label, name)
+}
+
+func (l storageLayout) index(ref storageRef, key string) (storageRef, error) {
+	switch {
+	case ref.typ.Encoding == "mapping":
+		keyType, err := l.typeOf(ref.typ.Key)
+		if err != nil {
+			return storageRef{}, err
+		}
+		encoded, err := encodeMappingKey(keyType.Label, key)
+		if err != nil {
+			return storageRef{}, err
+		}
+		valueType, err := l.typeOf(ref.typ.Value)
+		if err != nil {
+			return storageRef{}, err
+		}
+		slot := crypto.Keccak256(encoded, math.U256Bytes(new(big.Int).Set(ref.slot)))
+		return storageRef{slot: new(big.Int).SetBytes(slot), typ: valueType}, nil
+	case ref.typ.Base != "":
+		i, ok := new(big.Int).SetString(key, 0)
+		if !ok || i.Sign() < 0 {
+			return storageRef{}, fmt.Errorf("array index must be a non negative number, index=%s", key)
+		}
+		base, err := l.typeOf(ref.typ.Base)
+		if err != nil {
+			return storageRef{}, err
+		}
+		start := ref.slot
+		if ref.typ.Encoding == "dynamic_array" {
+			start = slotHash(ref.slot)
+		} else if length, ok := ref.typ.length(); ok && i.Cmp(length) >= 0 {
+			return storageRef{}, fmt.Errorf("array index is out of range, index=%s, length=%s", key, length)
+		}
+		// elements smaller than 32 bytes are packed, others take whole slots
+		size := base.size()
+		if size > 0 && size < 32 {
+			perSlot := big.NewInt(int64(32 / size))
+			slot, offset := new(big.Int).QuoRem(i, perSlot, new(big.Int))
+			return storageRef{
+				slot:   slot.Add(slot, start),
+				offset: int(offset.Int64()) * size,
+				typ:    base,
+			}, nil
+		}
+		slots := big.NewInt(int64((size + 31) / 32))
+		return storageRef{slot: new(big.Int).Add(start, new(big.Int).Mul(i, slots)), typ: base}, nil
+	}
+	return storageRef{}, fmt.Errorf("%s cannot be indexed", ref.typ.Label)
+}
+
+// encodeMappingKey encodes key as solidity does to compute slot of mapping values
+func encodeMappingKey(label, key string) ([]byte, error) {
+	key = strings.Trim(key, `"'`)
+	switch {
+	case label == "address" || strings.HasPrefix(label, "contract "):
+		if !ethereum.IsHexAddress(key) {
+			return nil, fmt.Errorf("mapping key is not a valid address, key=%s", key)
+		}
+		return ethereum.LeftPadBytes(ethereum.HexToAddress(key).Bytes(), 32), nil
+	case label == "bool":
+		b, err := strconv.ParseBool(key)
+		if err != nil {
+			return nil, fmt.Errorf("mapping key is not a bool, key=%s", key)
+		}
+		if b {
+			return math.U256Bytes(big.NewInt(1)), nil
+		}
+		return make([]byte, 32), nil
+	case strings.HasPrefix(label, "uint") || strings.HasPrefix(label, "int") || strings.HasPrefix(label, "enum "):
+		n, ok := new(big.Int).SetString(key, 0)
+		if !ok {
+			return nil, fmt.Errorf("mapping key is not a number, key=%s", key)
+		}
+		return math.U256Bytes(n), nil
+	case label == "string":
+		return []byte(key), nil
+	case label == "bytes":
+		return hexutil.Decode(key)
+	case strings.HasPrefix(label, "bytes"):
+		b, err := hexutil.Decode(key)
+		if err != nil {
+			return nil, err
+		}
+		return ethereum.RightPadBytes(b, 32), nil
+	}
+	return nil, fmt.Errorf("unsupported mapping key type %s", label)
+}
+
+// decodeStorageValue decodes a value of an inplace type taken from a slot
+func decodeStorageValue(label string, b []byte) interface{} {
+	switch {
+	case label == "bool":
+		return b[len(b)-1] != 0
+	case strings.HasPrefix(label, "address") || strings.HasPrefix(label, "contract "):
+		return ethereum.BytesToAddress(b).Hex()
+	case strings.HasPrefix(label, "uint") || strings.HasPrefix(label, "enum "):
+		return new(big.Int).SetBytes(b).String()
+	case strings.HasPrefix(label, "int"):
+		n := new(big.Int).SetBytes(b)
+		if len(b) > 0 && b[0]&0x80 != 0 {
+			n.Sub(n, new(big.Int).Lsh(big.NewInt(1), uint(len(b)*8)))
+		}
+		return n.String()
+	}
+	return hexutil.Encode(b)
+}
+
+// slotReader reads storage slots of a contract at a block, caching slots already read
+type slotReader struct {
+	read  func(slot ethereum.Hash) ([]byte, error)
+	cache map[ethereum.Hash][]byte
+}
+
+func (r *slotReader) word(slot *big.Int) ([]byte, error) {
+	key := ethereum.BigToHash(slot)
+	if w, ok := r.cache[key]; ok {
+		return w, nil
+	}
+	w, err := r.read(key)
+	if err != nil {
+		return nil, err
+	}
+	w = ethereum.LeftPadBytes(w, 32)
+	r.cache[key] = w
+	return w, nil
+}
+
+// value reads a resolved variable, the length is returned for dynamic arrays
+func (r *slotReader) value(ref storageRef) (value interface{}, length string, err error) {
+	word, err := r.word(ref.slot)
+	if err != nil {
+		return nil, "", err
+	}
+	switch {
+	case ref.typ.Encoding == "mapping":
+		return nil, "", fmt.Errorf("mapping needs a key, e.g. variable[key]")
+	case ref.typ.Encoding == "dynamic_array":
+		return nil, new(big.Int).SetBytes(word).String(), nil
+	case ref.typ.Encoding == "bytes":
+		data, err := r.bytes(ref.slot, word)
+		if err != nil {
+			return nil, "", err
+		}
+		if ref.typ.Label == "string" {
+			return string(data), "", nil
+		}
+		return hexutil.Encode(data), "", nil
+	case len(ref.typ.Members) != 0:
+		var members []string
+		for _, m := range ref.typ.Members {
+			members = append(members, m.Label)
+		}
+		return nil, "", fmt.Errorf("struct needs a member, members=%s", strings.Join(members, ","))
+	case ref.typ.Base != "":
+		return nil, "", fmt.Errorf("static array needs an index, e.g. variable[0]")
+	}
+	size := ref.typ.size()
+	if size <= 0 || ref.offset+size > 32 {
+		return nil, "", fmt.Errorf("unsupported type %s", ref.typ.Label)
+	}
+	return decodeStorageValue(ref.typ.Label, word[32-ref.offset-size:32-ref.offset]), "", nil
+}
+
+// bytes reads a string or bytes variable, short values (< 32 bytes) are kept in the slot with length*2,
+// long ones keep length*2+1 in the slot and data from keccak(slot)
+func (r *slotReader) bytes(slot *big.Int, word []byte) ([]byte, error) {
+	if word[31]&1 == 0 {
+		// short values have at most 31 bytes, anything else means the slot does not hold a string or bytes
+		if word[31]/2 > 31 {
+			return nil, fmt.Errorf("slot does not hold a short string or bytes, length byte=%d", word[31])
+		}
+		return word[:word[31]/2], nil
+	}
+	length := new(big.Int).Rsh(new(big.Int).SetBytes(word), 1)
+	if !length.IsInt64() || length.Int64() > maxStorageBytesLength {
+		return nil, fmt.Errorf("value is too long, length=%s", length)
+	}
+	n := int(length.Int64())
+	data := make([]byte, 0, n+31)
+	start := slotHash(slot)
+	for i := 0; len(data) < n; i++ {
+		w, err := r.word(new(big.Int).Add(start, big.NewInt(int64(i))))
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, w...)
+	}
+	return data[:n], nil
+}
+
+// ReadStorage reads raw slots and state variables of contract at a block. Variables are resolved with the
+// compiler storage layout (solc storageLayout output, or an artifact holding it); the stored layout of contract
+// is used when layout is empty, a given layout is stored when rememberBy (the actor) is not empty. Variables
+// are paths like owner, balances[0x..], items[2].amount.
+func (c *Core) ReadStorage(contract ethereum.Address, slots, variables []string, layout, rememberBy string,
+	blockNumber, customNode string) (common.StorageResult, error) {
+	l := c.l.With("func", "core/ReadStorage", "contract", contract.Hex())
+	var parsed storageLayout
+	if len(variables) != 0 {
+		if layout == "" {
+			stored, err := c.s.GetStorageLayout(contract)
+			if err != nil {
+				return common.StorageResult{}, err
+			}
+			if stored == "" {
+				return common.StorageResult{}, notFoundError("no storage layout for contract, upload the compiler storage layout")
+			}
+			layout, rememberBy = stored, ""
+		}
+		var err error
+		if parsed, err = parseStorageLayout(layout); err != nil {
+			return common.StorageResult{}, err
+		}
+		if rememberBy != "" {
+			if err := c.s.StoreStorageLayout(contract, layout, rememberBy); err != nil {
+				l.Errorw("cannot store storage layout", "err", err)
+			}
+		}
+	}
+	bn, err := ParseBlockNumber(blockNumber)
+	if err != nil {
+		return common.StorageResult{}, err
+	}
+	eclient, err := c.nodeClient(customNode)
+	if err != nil {
+		return common.StorageResult{}, err
+	}
+	if eclient != c.ecli {
+		defer eclient.Close()
+	}
+	if bn == nil {
+		head, err := eclient.HeaderByNumber(context.Background(), nil)
+		if err != nil {
+			return common.StorageResult{}, upstreamError(err, "cannot get latest block, err=%s", err)
+		}
+		bn = head.Number
+	}
+	r := &slotReader{
+		read: func(slot ethereum.Hash) ([]byte, error) {
+			return eclient.StorageAt(context.Background(), contract, slot, bn)
+		},
+		cache: make(map[ethereum.Hash][]byte),
+	}
+	result := common.StorageResult{
+		BlockNumber: bn.Uint64(),
+		Slots:       []common.StorageSlot{},
+		Variables:   []common.StorageVariable{},
+	}
+	for _, s := range slots {
+		slot, err := parseSlot(s)
+		if err != nil {
+			return common.StorageResult{}, err
+		}
+		word, err := r.word(slot)
+		if err != nil {
+			return common.StorageResult{}, upstreamError(err, "cannot read slot, slot=%s, err=%s", s, err)
+		}
+		result.Slots = append(result.Slots, common.StorageSlot{
+			Slot:  hexutil.EncodeBig(slot),
+			Value: hexutil.Encode(word),
+		})
+	}
+	for _, v := range variables {
+		result.Variables = append(result.Variables, r.variable(parsed, v))
+	}
+	return result, nil
+}
+
+func (r *slotReader) variable(layout storageLayout, path string) common.StorageVariable {
+	v := common.StorageVariable{Variable: path}
+	ref, err := layout.resolve(path)
+	if err != nil {
+		v.Err = err.Error()
+		return v
+	}
+	v.Type, v.Slot, v.Offset = ref.typ.Label, hexutil.EncodeBig(ref.slot), ref.offset
+	if v.Value, v.Length, err = r.value(ref); err != nil {
+		v.Err = err.Error()
+	}
+	return v
+}
+
+// LayoutAuditLog returns latest storage layout writes, of contract if it is not empty
+func (c *Core) LayoutAuditLog(contract string, limit int) ([]common.LayoutAudit, error) {
+	if contract != "" {
+		if !ethereum.IsHexAddress(contract) {
+			return nil, argumentError("contract", "contract is not a valid ethereum address")
+		}
+		contract = ethereum.HexToAddress(contract).Hex()
+	}
+	if limit <= 0 || limit > maxABIAuditLimit {
+		limit = maxABIAuditLimit
+	}
+	return c.s.GetLayoutAuditLog(contract, limit)
+}
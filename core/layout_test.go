@@ -0,0 +1,115 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	ethereum "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/require"
+)
+
+const testStorageLayout = `{"storageLayout": {
+	"storage": [
+		{"label": "balances", "offset": 0, "slot": "0", "type": "t_mapping(t_uint256,t_uint256)"},
+		{"label": "owner", "offset": 0, "slot": "1", "type": "t_address"},
+		{"label": "paused", "offset": 20, "slot": "1", "type": "t_bool"},
+		{"label": "delta", "offset": 21, "slot": "1", "type": "t_int8"},
+		{"label": "items", "offset": 0, "slot": "2", "type": "t_array(t_struct(Item)_storage)dyn_storage"},
+		{"label": "name", "offset": 0, "slot": "3", "type": "t_string_storage"},
+		{"label": "limits", "offset": 0, "slot": "4", "type": "t_array(t_uint256)2_storage"}
+	],
+	"types": {
+		"t_address": {"encoding": "inplace", "label": "address", "numberOfBytes": "20"},
+		"t_bool": {"encoding": "inplace", "label": "bool", "numberOfBytes": "1"},
+		"t_int8": {"encoding": "inplace", "label": "int8", "numberOfBytes": "1"},
+		"t_uint128": {"encoding": "inplace", "label": "uint128", "numberOfBytes": "16"},
+		"t_uint256": {"encoding": "inplace", "label": "uint256", "numberOfBytes": "32"},
+		"t_string_storage": {"encoding": "bytes", "label": "string", "numberOfBytes": "32"},
+		"t_array(t_uint256)2_storage": {"encoding": "inplace", "base": "t_uint256", "label": "uint256[2]", "numberOfBytes": "64"},
+		"t_mapping(t_uint256,t_uint256)": {"encoding": "mapping", "key": "t_uint256", "label": "mapping(uint256 => uint256)", "numberOfBytes": "32", "value": "t_uint256"},
+		"t_array(t_struct(Item)_storage)dyn_storage": {"encoding": "dynamic_array", "base": "t_struct(Item)_storage", "label": "struct Item[]", "numberOfBytes": "32"},
+		"t_struct(Item)_storage": {"encoding": "inplace", "label": "struct Item", "numberOfBytes": "64", "members": [
+			{"label": "amount", "offset": 0, "slot": "0", "type": "t_uint256"},
+			{"label": "start", "offset": 0, "slot": "1", "type": "t_uint128"},
+			{"label": "end", "offset": 16, "slot": "1", "type": "t_uint128"}
+		]}
+	}
+}}`
+
+func TestStorageLayoutResolve(t *testing.T) {
+	layout, err := parseStorageLayout(testStorageLayout)
+	require.NoError(t, err)
+
+	ref, err := layout.resolve("balances[0]")
+	require.NoError(t, err)
+	require.Equal(t, "0xad3228b676f7d3cd4284a5443f17f1962b36e491b30a40b2405849e597ba5fb5", hexutil.EncodeBig(ref.slot))
+
+	ref, err = layout.resolve("paused")
+	require.NoError(t, err)
+	require.Equal(t, int64(1), ref.slot.Int64())
+	require.Equal(t, 20, ref.offset)
+
+	// items data starts at keccak(2), each item takes 2 slots
+	ref, err = layout.resolve("items[1].end")
+	require.NoError(t, err)
+	start := slotHash(big.NewInt(2))
+	require.Equal(t, new(big.Int).Add(start, big.NewInt(3)), ref.slot)
+	require.Equal(t, 16, ref.offset)
+	require.Equal(t, "uint128", ref.typ.Label)
+
+	_, err = layout.resolve("items[1].missing")
+	require.Error(t, err)
+
+	ref, err = layout.resolve("limits[1]")
+	require.NoError(t, err)
+	require.Equal(t, int64(5), ref.slot.Int64())
+	_, err = layout.resolve("limits[2]")
+	require.Error(t, err)
+	_, err = layout.resolve("unknown")
+	require.Error(t, err)
+	_, err = layout.resolve("owner[1]")
+	require.Error(t, err)
+}
+
+func TestStorageLayoutValue(t *testing.T) {
+	layout, err := parseStorageLayout(testStorageLayout)
+	require.NoError(t, err)
+	slots := map[ethereum.Hash][]byte{
+		// delta = -2, paused = true, owner
+		ethereum.BigToHash(big.NewInt(1)): hexutil.MustDecode("0x00000000000000000000fe01bc5b5c036eb41a1a85af0b4da13d56420e8a0a92"),
+		ethereum.BigToHash(big.NewInt(2)): ethereum.LeftPadBytes([]byte{3}, 32),
+		// short string "abc"
+		ethereum.BigToHash(big.NewInt(3)): append([]byte("abc"), append(make([]byte, 28), 6)...),
+	}
+	r := &slotReader{
+		read: func(slot ethereum.Hash) ([]byte, error) {
+			return slots[slot], nil
+		},
+		cache: make(map[ethereum.Hash][]byte),
+	}
+	v := r.variable(layout, "owner")
+	require.Empty(t, v.Err)
+	require.Equal(t, "0xBc5B5c036Eb41A1A85AF0B4Da13D56420e8A0a92", v.Value)
+	require.Equal(t, true, r.variable(layout, "paused").Value)
+	require.Equal(t, "-2", r.variable(layout, "delta").Value)
+	require.Equal(t, "3", r.variable(layout, "items").Length)
+	require.Equal(t, "abc", r.variable(layout, "name").Value)
+	require.Equal(t, "0", r.variable(layout, "balances[0]").Value)
+	require.NotEmpty(t, r.variable(layout, "balances").Err)
+	require.NotEmpty(t, r.variable(layout, "items[0]").Err)
+
+	// long string keeps length*2+1 in the slot and data from keccak(slot)
+	long := []byte("a string longer than thirty two bytes")
+	slots[ethereum.BigToHash(big.NewInt(3))] = ethereum.LeftPadBytes([]byte{byte(len(long)*2 + 1)}, 32)
+	start := slotHash(big.NewInt(3))
+	slots[ethereum.BigToHash(start)] = long[:32]
+	slots[ethereum.BigToHash(new(big.Int).Add(start, big.NewInt(1)))] = ethereum.RightPadBytes(long[32:], 32)
+	r.cache = make(map[ethereum.Hash][]byte)
+	require.Equal(t, string(long), r.variable(layout, "name").Value)
+
+	// an even length byte over 62 is not a short string, the slot holds another value
+	slots[ethereum.BigToHash(big.NewInt(3))] = ethereum.LeftPadBytes([]byte{0xf0}, 32)
+	r.cache = make(map[ethereum.Hash][]byte)
+	require.NotEmpty(t, r.variable(layout, "name").Err)
+}
@@ -0,0 +1,182 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	goethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethereum "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"github.com/KyberNetwork/contract-caller/common"
+	cc "github.com/KyberNetwork/contract-caller/lib/contract-caller"
+)
+
+const (
+	// multicallAddress is Multicall3, deployed at the same address on all supported networks
+	multicallAddress = "0xcA11bde05977b3631167028862bE2a173976CA11"
+	// multicallChunkSize is max number of calls aggregated in one eth_call
+	multicallChunkSize = 100
+
+	multicallABI = `[
+		{"inputs":[{"name":"requireSuccess","type":"bool"},{"components":[{"name":"target","type":"address"},{"name":"callData","type":"bytes"}],"name":"calls","type":"tuple[]"}],"name":"tryAggregate","outputs":[{"components":[{"name":"success","type":"bool"},{"name":"returnData","type":"bytes"}],"name":"returnData","type":"tuple[]"}],"stateMutability":"view","type":"function"},
+		{"inputs":[{"name":"addr","type":"address"}],"name":"getEthBalance","outputs":[{"name":"balance","type":"uint256"}],"stateMutability":"view","type":"function"}
+	]`
+)
+
+type multicallCall struct {
+	Target   ethereum.Address
+	CallData []byte
+}
+
+type multicallResult struct {
+	Success    bool
+	ReturnData []byte
+}
+
+// Multicall executes calls at the same block, aggregated through Multicall3 when it is deployed on the node's
+// network, one by one otherwise. A failing call does not fail the others.
+func (c *Core) Multicall(calls []common.CallRequest, blockNumber, customNode string) ([]common.CallResult, error) {
+	bn, err := ParseBlockNumber(blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	eclient, err := c.nodeClient(customNode)
+	if err != nil {
+		return nil, err
+	}
+	if eclient != c.ecli {
+		defer eclient.Close()
+	}
+	if bn == nil {
+		// pin latest block so all chunks see the same state
+		head, err := eclient.HeaderByNumber(context.Background(), nil)
+		if err != nil {
+			return nil, upstreamError(err, "cannot get latest block, err=%s", err)
+		}
+		bn = head.Number
+	}
+	results := make([]common.CallResult, len(calls))
+	prepared := make([]*preparedCall, len(calls))
+	for i, call := range calls {
+		if !ethereum.IsHexAddress(call.Contract) {
+			results[i].Err = fmt.Sprintf("contract is not a valid ethereum address, contract=%s", call.Contract)
+			continue
+		}
+		pc, err := c.prepareCall(ethereum.HexToAddress(call.Contract), call.ABI, call.Method, call.Params)
+		if err != nil {
+			results[i].Err = err.Error()
+			continue
+		}
+		prepared[i] = pc
+	}
+	var (
+		indexes []int
+		raw     []multicallCall
+	)
+	for i, pc := range prepared {
+		if pc != nil {
+			indexes = append(indexes, i)
+			raw = append(raw, multicallCall{Target: pc.contract, CallData: pc.data})
+		}
+	}
+	out, err := c.aggregate(eclient, bn, raw)
+	if err != nil {
+		return nil, err
+	}
+	for j, i := range indexes {
+		results[i] = unpackCallOutcome(prepared[i], out[j])
+	}
+	return results, nil
+}
+
+// callOutcome is raw result of an aggregated call, err is set when a call sent without multicall failed
+type callOutcome struct {
+	success bool
+	data    []byte
+	err     string
+}
+
+// aggregate executes raw calls at block bn through Multicall3 in chunks, or one by one when Multicall3 is
+// not deployed
+func (c *Core) aggregate(eclient *ethclient.Client, bn *big.Int, calls []multicallCall) ([]callOutcome, error) {
+	outcomes := make([]callOutcome, 0, len(calls))
+	if !c.hasMulticall(eclient, bn) {
+		for _, call := range calls {
+			to := call.Target
+			data, err := eclient.CallContract(context.Background(), goethereum.CallMsg{To: &to, Data: call.CallData}, bn)
+			if err != nil {
+				outcomes = append(outcomes, callOutcome{err: err.Error()})
+				continue
+			}
+			outcomes = append(outcomes, callOutcome{success: true, data: data})
+		}
+		return outcomes, nil
+	}
+	for start := 0; start < len(calls); start += multicallChunkSize {
+		end := start + multicallChunkSize
+		if end > len(calls) {
+			end = len(calls)
+		}
+		out, err := c.tryAggregate(eclient, bn, calls[start:end])
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range out {
+			outcomes = append(outcomes, callOutcome{success: r.Success, data: r.ReturnData})
+		}
+	}
+	return outcomes, nil
+}
+
+// hasMulticall checks whether Multicall3 is deployed at block bn
+func (c *Core) hasMulticall(eclient *ethclient.Client, bn *big.Int) bool {
+	code, err := eclient.CodeAt(context.Background(), ethereum.HexToAddress(multicallAddress), bn)
+	if err != nil {
+		c.l.Warnw("cannot get multicall code", "err", err)
+		return false
+	}
+	return len(code) != 0
+}
+
+func (c *Core) tryAggregate(eclient *ethclient.Client, bn *big.Int, calls []multicallCall) ([]multicallResult, error) {
+	mABI, err := abi.JSON(strings.NewReader(multicallABI))
+	if err != nil {
+		return nil, err
+	}
+	caller := cc.NewContractCaller(mABI, eclient, ethereum.HexToAddress(multicallAddress))
+	out, err := caller.Call(&bind.CallOpts{BlockNumber: bn}, "tryAggregate", false, calls)
+	if err != nil {
+		return nil, upstreamError(err, "cannot call multicall, err=%s", err)
+	}
+	if len(out) != 1 {
+		return nil, fmt.Errorf("unexpected multicall output, length=%d", len(out))
+	}
+	results := *abi.ConvertType(out[0], new([]multicallResult)).(*[]multicallResult)
+	if len(results) != len(calls) {
+		return nil, fmt.Errorf("unexpected multicall result, expected=%d, actual=%d", len(calls), len(results))
+	}
+	return results, nil
+}
+
+func unpackCallOutcome(pc *preparedCall, o callOutcome) common.CallResult {
+	if o.err != "" {
+		return common.CallResult{Err: fmt.Sprintf("cannot get data from contract, err=%s", o.err)}
+	}
+	if !o.success {
+		reason, err := abi.UnpackRevert(o.data)
+		if err != nil {
+			return common.CallResult{Err: "execution reverted"}
+		}
+		return common.CallResult{Err: fmt.Sprintf("execution reverted: %s", reason)}
+	}
+	result, err := pc.cABI.Unpack(pc.method, o.data)
+	if err != nil {
+		return common.CallResult{Err: fmt.Sprintf("cannot unpack result, err=%s", err)}
+	}
+	return common.CallResult{Result: result}
+}
@@ -0,0 +1,295 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/gorilla/websocket"
+
+	libhttp "github.com/KyberNetwork/contract-caller/lib/http"
+)
+
+// defaultNodeSchemes are url schemes of custom nodes when a policy does not set them
+var defaultNodeSchemes = []string{"http", "https", "ws", "wss"}
+
+// privateNets are loopback, private, link-local, shared, documentation and multicast ranges, which are not public
+// nodes
+var privateNets = parseNets(
+	"0.0.0.0/8", "10.0.0.0/8", "100.64.0.0/10", "127.0.0.0/8", "169.254.0.0/16", "172.16.0.0/12",
+	"192.0.0.0/24", "192.0.2.0/24", "192.168.0.0/16", "198.18.0.0/15", "198.51.100.0/24", "203.0.113.0/24",
+	"224.0.0.0/4", "240.0.0.0/4",
+	"::/128", "::1/128", "64:ff9b::/96", "2001:db8::/32", "fc00::/7", "fe80::/10", "ff00::/8",
+)
+
+func parseNets(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}
+
+// NodePolicy restricts custom nodes the server connects to, so clients cannot make it reach internal addresses.
+// Host entries are a host name, ".example.com" for its subdomains, an ip or a cidr range
+type NodePolicy struct {
+	// Schemes are allowed url schemes, http, https, ws and wss if empty
+	Schemes []string
+	// AllowHosts are the only hosts allowed if not empty, they may resolve to private addresses
+	AllowHosts []string
+	// DenyHosts are never allowed, cidr ranges are also matched against resolved addresses
+	DenyHosts []string
+	// AllowPrivate allows hosts resolving to private addresses
+	AllowPrivate bool
+}
+
+// hostRule is a parsed host entry of a policy
+type hostRule struct {
+	name   string
+	suffix string
+	ipNet  *net.IPNet
+}
+
+func parseHostRule(entry string) (hostRule, error) {
+	entry = strings.ToLower(strings.TrimSpace(entry))
+	switch {
+	case entry == "":
+		return hostRule{}, fmt.Errorf("empty host entry")
+	case strings.Contains(entry, "/"):
+		_, n, err := net.ParseCIDR(entry)
+		if err != nil {
+			return hostRule{}, fmt.Errorf("invalid cidr range %s, err: %s", entry, err)
+		}
+		return hostRule{ipNet: n}, nil
+	case strings.HasPrefix(entry, "*."):
+		return hostRule{suffix: entry[1:]}, nil
+	case strings.HasPrefix(entry, "."):
+		return hostRule{suffix: entry}, nil
+	case net.ParseIP(entry) != nil:
+		ip := net.ParseIP(entry)
+		if ip4 := ip.To4(); ip4 != nil {
+			ip = ip4
+		}
+		return hostRule{ipNet: &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)}}, nil
+	}
+	return hostRule{name: entry}, nil
+}
+
+// matchHost tells whether host (a name or an ip literal) matches the rule
+func (r hostRule) matchHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if r.ipNet != nil {
+		ip := net.ParseIP(host)
+		return ip != nil && r.ipNet.Contains(ip)
+	}
+	if r.suffix != "" {
+		return strings.HasSuffix(host, r.suffix)
+	}
+	return host == r.name
+}
+
+// nodeDialer connects to custom nodes under a policy. Host names are resolved once and the checked addresses
+// are dialed, so a name cannot resolve to a public address when checked and to an internal one when dialed
+type nodeDialer struct {
+	schemes      map[string]bool
+	allow        []hostRule
+	deny         []hostRule
+	allowPrivate bool
+	dialer       *net.Dialer
+	hc           *http.Client
+	ws           websocket.Dialer
+}
+
+func newNodeDialer(p NodePolicy) (*nodeDialer, error) {
+	d := &nodeDialer{
+		schemes:      make(map[string]bool),
+		allowPrivate: p.AllowPrivate,
+		dialer:       &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second},
+	}
+	schemes := p.Schemes
+	if len(schemes) == 0 {
+		schemes = defaultNodeSchemes
+	}
+	for _, scheme := range schemes {
+		scheme = strings.ToLower(scheme)
+		if scheme != "http" && scheme != "https" && scheme != "ws" && scheme != "wss" {
+			return nil, fmt.Errorf("unsupported node scheme %s, supported=http,https,ws,wss", scheme)
+		}
+		d.schemes[scheme] = true
+	}
+	for _, entry := range p.AllowHosts {
+		r, err := parseHostRule(entry)
+		if err != nil {
+			return nil, err
+		}
+		d.allow = append(d.allow, r)
+	}
+	for _, entry := range p.DenyHosts {
+		r, err := parseHostRule(entry)
+		if err != nil {
+			return nil, err
+		}
+		d.deny = append(d.deny, r)
+	}
+	// proxies from environment are not used, they would dial on behalf of the server without the checks
+	d.hc = &http.Client{Transport: &http.Transport{
+		DialContext:           d.dialContext,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}}
+	d.ws = websocket.Dialer{NetDialContext: d.dialContext, HandshakeTimeout: 10 * time.Second}
+	return d, nil
+}
+
+// webhookClient returns client of alert webhooks, dialing under the policy as custom nodes do
+func (d *nodeDialer) webhookClient() *libhttp.RestClient {
+	return libhttp.NewRestClient(&http.Client{Timeout: webhookTimeout, Transport: d.hc.Transport}).
+		WithRetry(libhttp.RetryTransient(webhookRetries, time.Second))
+}
+
+// NodeBlockedError is returned when a custom node is not allowed by the node policy
+type NodeBlockedError struct {
+	Host   string
+	Reason string
+}
+
+func (e *NodeBlockedError) Error() string {
+	return fmt.Sprintf("node host %s is not allowed, %s", e.Host, e.Reason)
+}
+
+func matchAny(rules []hostRule, host string) bool {
+	for _, r := range rules {
+		if r.matchHost(host) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkHost checks a host name or ip literal against allow and deny lists, it tells whether the host is
+// explicitly allowed
+func (d *nodeDialer) checkHost(host string) (bool, error) {
+	if matchAny(d.deny, host) {
+		return false, &NodeBlockedError{Host: host, Reason: "host is denied"}
+	}
+	if len(d.allow) == 0 {
+		return false, nil
+	}
+	if !matchAny(d.allow, host) {
+		return false, &NodeBlockedError{Host: host, Reason: "host is not in the allowlist"}
+	}
+	return true, nil
+}
+
+// checkIP checks an address host resolves to
+func (d *nodeDialer) checkIP(host string, ip net.IP, allowed bool) error {
+	ipHost := ip.String()
+	if matchAny(d.deny, ipHost) {
+		return &NodeBlockedError{Host: host, Reason: fmt.Sprintf("address %s is denied", ipHost)}
+	}
+	if allowed || d.allowPrivate {
+		return nil
+	}
+	for _, n := range privateNets {
+		if n.Contains(ip) {
+			return &NodeBlockedError{Host: host, Reason: fmt.Sprintf("address %s is not public", ipHost)}
+		}
+	}
+	return nil
+}
+
+// dialContext resolves addr, checks every resolved address and dials the checked ones
+func (d *nodeDialer) dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	allowed, err := d.checkHost(host)
+	if err != nil {
+		return nil, err
+	}
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	for _, ip := range ips {
+		if err := d.checkIP(host, ip.IP, allowed); err != nil {
+			return nil, err
+		}
+	}
+	var conn net.Conn
+	for _, ip := range ips {
+		if conn, err = d.dialer.DialContext(ctx, network, net.JoinHostPort(ip.IP.String(), port)); err == nil {
+			return conn, nil
+		}
+	}
+	if err == nil {
+		err = fmt.Errorf("no address of host %s", host)
+	}
+	return nil, err
+}
+
+// check validates url of a custom node before dialing it, argument names the input in errors
+func (d *nodeDialer) check(argument, node string) (*url.URL, error) {
+	return d.checkURL(argument, "custom node", node, d.schemes)
+}
+
+// checkWebhook validates url of a webhook, webhooks are posted to over http only
+func (d *nodeDialer) checkWebhook(argument, webhook string) error {
+	_, err := d.checkURL(argument, "webhook", webhook, map[string]bool{"http": true, "https": true})
+	return err
+}
+
+// checkURL validates scheme and host of a url the server connects to, what names it in errors
+func (d *nodeDialer) checkURL(argument, what, rawURL string, schemes map[string]bool) (*url.URL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return nil, argumentError(argument, "%s is not a valid url, url=%s", what, rawURL)
+	}
+	if !schemes[strings.ToLower(u.Scheme)] {
+		return nil, argumentError(argument, "%s scheme %s is not allowed, url=%s", what, u.Scheme, rawURL)
+	}
+	allowed, err := d.checkHost(u.Hostname())
+	if err == nil {
+		if ip := net.ParseIP(u.Hostname()); ip != nil {
+			err = d.checkIP(u.Hostname(), ip, allowed)
+		}
+	}
+	if err != nil {
+		e := argumentError(argument, "%s is not allowed, %s", what, err.Error())
+		e.Err = err
+		return nil, e
+	}
+	return u, nil
+}
+
+// dial checks and connects to a custom node
+func (d *nodeDialer) dial(argument, node string) (*ethclient.Client, error) {
+	u, err := d.check(argument, node)
+	if err != nil {
+		return nil, err
+	}
+	var client *rpc.Client
+	switch strings.ToLower(u.Scheme) {
+	case "ws", "wss":
+		client, err = rpc.DialWebsocketWithDialer(context.Background(), node, "", d.ws)
+	default:
+		client, err = rpc.DialHTTPWithClient(node, d.hc)
+	}
+	if err != nil {
+		return nil, upstreamError(err, "cannot connect to given node, node=%s", node)
+	}
+	return ethclient.NewClient(client), nil
+}
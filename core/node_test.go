@@ -0,0 +1,91 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodePolicyCheck(t *testing.T) {
+	d, err := newNodeDialer(NodePolicy{DenyHosts: []string{".internal.example.com", "8.8.4.0/24"}})
+	require.NoError(t, err)
+	for node, ok := range map[string]bool{
+		"https://mainnet.example.com":         true,
+		"wss://mainnet.example.com/ws":        true,
+		"ftp://mainnet.example.com":           false,
+		"/var/run/geth.ipc":                   false,
+		"http://127.0.0.1:8545":               false,
+		"http://[::1]:8545":                   false,
+		"http://169.254.169.254/latest":       false,
+		"http://10.1.2.3":                     false,
+		"https://node.internal.example.com":   false,
+		"https://8.8.4.4":                     false,
+		"https://8.8.8.8":                     true,
+		"http://[::ffff:192.168.1.1]:8545/rp": false,
+	} {
+		_, err := d.check("customNode", node)
+		if ok {
+			require.NoError(t, err, node)
+			continue
+		}
+		require.Error(t, err, node)
+		require.Equal(t, CodeValidation, AsError(err).Code, node)
+	}
+
+	_, err = newNodeDialer(NodePolicy{Schemes: []string{"file"}})
+	require.Error(t, err)
+
+	d, err = newNodeDialer(NodePolicy{AllowHosts: []string{"10.0.0.0/8", "node.example.com"}})
+	require.NoError(t, err)
+	_, err = d.check("customNode", "http://10.1.2.3:8545")
+	require.NoError(t, err)
+	_, err = d.check("customNode", "https://other.example.com")
+	require.Error(t, err)
+}
+
+func TestNodeDialBlocksResolvedAddress(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x1"}`))
+	}))
+	defer srv.Close()
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	port := u.Port()
+
+	// localhost passes url checks, its resolved loopback address is refused when dialing
+	d, err := newNodeDialer(NodePolicy{})
+	require.NoError(t, err)
+	ecli, err := d.dial("customNode", "http://localhost:"+port)
+	require.NoError(t, err)
+	_, err = networkFromNode(ecli)
+	require.Error(t, err)
+	e := AsError(err)
+	require.Equal(t, CodeValidation, e.Code)
+	require.Equal(t, "localhost", e.Details["host"])
+
+	d, err = newNodeDialer(NodePolicy{AllowPrivate: true})
+	require.NoError(t, err)
+	ecli, err = d.dial("customNode", "http://localhost:"+port)
+	require.NoError(t, err)
+	_, err = networkFromNode(ecli)
+	require.NoError(t, err)
+}
+
+func TestNodePolicyCheckWebhook(t *testing.T) {
+	d, err := newNodeDialer(NodePolicy{})
+	require.NoError(t, err)
+	require.NoError(t, d.checkWebhook("webhookURL", "https://hooks.example.com/services/x"))
+	for _, webhook := range []string{
+		"ws://hooks.example.com",
+		"http://169.254.169.254/latest/meta-data",
+		"http://127.0.0.1:8080/admin",
+		"hooks.example.com",
+	} {
+		err := d.checkWebhook("webhookURL", webhook)
+		require.Error(t, err, webhook)
+		require.Equal(t, "webhookURL", AsError(err).Details["argument"], webhook)
+	}
+}
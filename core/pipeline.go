@@ -0,0 +1,131 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethereum "github.com/ethereum/go-ethereum/common"
+
+	"github.com/KyberNetwork/contract-caller/common"
+	cc "github.com/KyberNetwork/contract-caller/lib/contract-caller"
+	"github.com/KyberNetwork/contract-caller/lib/render"
+)
+
+// pipelineRef matches ${step.output} references
+var pipelineRef = regexp.MustCompile(`\$\{([^}]*)\}`)
+
+// resolveRefs replaces every ${step.output} reference in s with the formatted output of an earlier step
+func resolveRefs(s string, steps map[string]common.PipelineStepResult) (string, error) {
+	var resolveErr error
+	resolved := pipelineRef.ReplaceAllStringFunc(s, func(ref string) string {
+		parts := strings.SplitN(pipelineRef.FindStringSubmatch(ref)[1], ".", 2)
+		step, ok := steps[parts[0]]
+		if !ok {
+			resolveErr = validationError("reference to unknown or later step, ref=%s", ref)
+			return ref
+		}
+		output := "0"
+		if len(parts) == 2 {
+			output = parts[1]
+		}
+		v, ok := step.Outputs[output]
+		if !ok {
+			resolveErr = validationError("step %s has no output %s, ref=%s", parts[0], output, ref)
+			return ref
+		}
+		return render.FormatParam(v)
+	})
+	return resolved, resolveErr
+}
+
+// RunPipeline executes steps in order at one pinned block (latest if blockNumber is empty). Contract and
+// string params of a step can reference outputs of earlier steps, e.g. ${getPair.pair}.
+func (c *Core) RunPipeline(steps []common.PipelineStep, blockNumber, network, customNode string) (common.PipelineResult, error) {
+	var result common.PipelineResult
+	bn, err := ParseBlockNumber(blockNumber)
+	if err != nil {
+		return result, err
+	}
+	eclient, err := c.nodeClient(customNode)
+	if err != nil {
+		return result, err
+	}
+	if eclient != c.ecli {
+		defer eclient.Close()
+	}
+	if bn == nil {
+		head, err := eclient.HeaderByNumber(context.Background(), nil)
+		if err != nil {
+			return result, upstreamError(err, "cannot get latest block, err=%s", err)
+		}
+		bn = head.Number
+	}
+	result.BlockNumber = bn.Uint64()
+
+	done := make(map[string]common.PipelineStepResult, len(steps))
+	for i, step := range steps {
+		if step.Name == "" {
+			step.Name = fmt.Sprintf("step%d", i+1)
+		}
+		if _, ok := done[step.Name]; ok {
+			return result, validationError("duplicated step name, step=%s", step.Name)
+		}
+		contractHex, err := resolveRefs(step.Contract, done)
+		if err != nil {
+			return result, err
+		}
+		if !ethereum.IsHexAddress(contractHex) {
+			return result, validationError("contract of step %s is not a valid ethereum address, contract=%s", step.Name, contractHex)
+		}
+		contract := ethereum.HexToAddress(contractHex)
+		stepParams, err := stringParams(step.Params)
+		if err != nil {
+			return result, wrapError(err, "step %s: %s", step.Name, AsError(err).Message)
+		}
+		params := make(map[string]interface{}, len(stepParams))
+		for k, v := range stepParams {
+			if ps, ok := v.(string); ok {
+				if v, err = resolveRefs(ps, done); err != nil {
+					return result, err
+				}
+			}
+			params[k] = v
+		}
+		contractABI := step.ABI
+		if contractABI == "" {
+			if contractABI, err = c.ContractABI(contract, network); err != nil {
+				return result, wrapError(err, "step %s: %s", step.Name, err.Error())
+			}
+		}
+		pc, err := c.prepareCall(contract, contractABI, step.Method, params)
+		if err != nil {
+			return result, wrapError(err, "step %s: %s", step.Name, err.Error())
+		}
+		caller := cc.NewContractCaller(pc.cABI, eclient, contract)
+		out, err := caller.CallWithInput(&bind.CallOpts{BlockNumber: bn}, step.Method, pc.data)
+		if err != nil {
+			return result, upstreamError(err, "step %s: cannot get data from contract, err=%s", step.Name, err)
+		}
+		outputs := make(map[string]interface{}, 2*len(out))
+		for j, o := range out {
+			outputs[strconv.Itoa(j)] = o
+			if name := pc.cABI.Methods[step.Method].Outputs[j].Name; name != "" {
+				outputs[name] = o
+			}
+		}
+		sr := common.PipelineStepResult{
+			Name:     step.Name,
+			Contract: contract.Hex(),
+			Method:   step.Method,
+			Params:   params,
+			Outputs:  outputs,
+		}
+		done[step.Name] = sr
+		result.Steps = append(result.Steps, sr)
+	}
+	return result, nil
+}
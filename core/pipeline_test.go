@@ -0,0 +1,36 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	ethereum "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KyberNetwork/contract-caller/common"
+)
+
+func TestResolveRefs(t *testing.T) {
+	pair := ethereum.HexToAddress("0xbc5b5c036eb41a1a85af0b4da13d56420e8a0a92")
+	steps := map[string]common.PipelineStepResult{
+		"getPair": {
+			Outputs: map[string]interface{}{"0": pair, "pair": pair},
+		},
+		"getReserves": {
+			Outputs: map[string]interface{}{"0": big.NewInt(10), "1": big.NewInt(20), "reserve1": big.NewInt(20)},
+		},
+	}
+
+	resolved, err := resolveRefs("${getPair.pair}", steps)
+	require.NoError(t, err)
+	require.Equal(t, pair.Hex(), resolved)
+
+	resolved, err = resolveRefs("${getReserves}/${getReserves.reserve1}", steps)
+	require.NoError(t, err)
+	require.Equal(t, "10/20", resolved)
+
+	_, err = resolveRefs("${getReserves.reserve0}", steps)
+	require.Error(t, err)
+	_, err = resolveRefs("${later.pair}", steps)
+	require.Error(t, err)
+}
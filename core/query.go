@@ -0,0 +1,121 @@
+package core
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum/common"
+
+	"github.com/KyberNetwork/contract-caller/common"
+)
+
+// queryIDLength is number of random bytes of a saved query permalink id
+const queryIDLength = 8
+
+func newQueryID() (string, error) {
+	b := make([]byte, queryIDLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func normalizeTags(tags []string) []string {
+	var result []string
+	for _, t := range tags {
+		t = strings.ToLower(strings.TrimSpace(strings.ReplaceAll(t, ",", "")))
+		if t != "" {
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+func validateQuery(q common.SavedQuery) error {
+	if q.Name == "" {
+		return argumentError("name", "query name is required")
+	}
+	if !ethereum.IsHexAddress(q.Contract) {
+		return argumentError("contract", "contract is not a valid ethereum address")
+	}
+	if q.Method == "" {
+		return argumentError("method", "query method is required")
+	}
+	if _, err := ParseBlockNumber(q.BlockNumber); err != nil {
+		return err
+	}
+	return nil
+}
+
+// SaveQuery stores a new saved query and returns it with its permalink id
+func (c *Core) SaveQuery(q common.SavedQuery) (common.SavedQuery, error) {
+	if err := validateQuery(q); err != nil {
+		return q, err
+	}
+	id, err := newQueryID()
+	if err != nil {
+		return q, err
+	}
+	q.ID = id
+	q.Contract = ethereum.HexToAddress(q.Contract).Hex()
+	q.Tags = normalizeTags(q.Tags)
+	q.CreatedAt = time.Now().Unix()
+	return q, c.s.StoreQuery(q)
+}
+
+// UpdateQuery replaces saved query of given id, keeping its creation time
+func (c *Core) UpdateQuery(id string, q common.SavedQuery) (common.SavedQuery, error) {
+	old, err := c.s.GetQuery(id)
+	if err != nil {
+		return q, err
+	}
+	if old == nil {
+		return q, notFoundError("query not found, id=%s", id)
+	}
+	if err := validateQuery(q); err != nil {
+		return q, err
+	}
+	q.ID = id
+	q.Contract = ethereum.HexToAddress(q.Contract).Hex()
+	q.Tags = normalizeTags(q.Tags)
+	q.CreatedAt = old.CreatedAt
+	return q, c.s.StoreQuery(q)
+}
+
+// Query returns saved query by its permalink id
+func (c *Core) Query(id string) (common.SavedQuery, error) {
+	q, err := c.s.GetQuery(id)
+	if err != nil {
+		return common.SavedQuery{}, err
+	}
+	if q == nil {
+		return common.SavedQuery{}, notFoundError("query not found, id=%s", id)
+	}
+	return *q, nil
+}
+
+// SearchQueries returns saved queries filtered by tag and text
+func (c *Core) SearchQueries(tag, text string) ([]common.SavedQuery, error) {
+	return c.s.SearchQueries(strings.ToLower(strings.TrimSpace(tag)), strings.TrimSpace(text))
+}
+
+// DeleteQuery ...
+func (c *Core) DeleteQuery(id string) error {
+	return c.s.DeleteQuery(id)
+}
+
+// RunQuery executes saved query of given id
+func (c *Core) RunQuery(id, customNode string) (interface{}, error) {
+	q, err := c.Query(id)
+	if err != nil {
+		return nil, err
+	}
+	contract := ethereum.HexToAddress(q.Contract)
+	contractABI, err := c.ContractABI(contract, q.Network)
+	if err != nil {
+		return nil, err
+	}
+	return c.CallContract(contract, contractABI, q.Method, q.BlockNumber, q.Params, customNode)
+}
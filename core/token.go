@@ -0,0 +1,245 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethereum "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"github.com/KyberNetwork/contract-caller/common"
+	cc "github.com/KyberNetwork/contract-caller/lib/contract-caller"
+	"github.com/KyberNetwork/contract-caller/lib/render"
+)
+
+// tokenABI covers view methods of ERC-20, ERC-721, ERC-1155 and ERC-165 used by token helpers,
+// overloaded balanceOf of ERC-1155 is named balanceOf1155
+const tokenABI = `[
+	{"inputs":[],"name":"name","outputs":[{"name":"","type":"string"}],"stateMutability":"view","type":"function"},
+	{"inputs":[],"name":"symbol","outputs":[{"name":"","type":"string"}],"stateMutability":"view","type":"function"},
+	{"inputs":[],"name":"decimals","outputs":[{"name":"","type":"uint8"}],"stateMutability":"view","type":"function"},
+	{"inputs":[],"name":"totalSupply","outputs":[{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"},
+	{"inputs":[{"name":"owner","type":"address"}],"name":"balanceOf","outputs":[{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"},
+	{"inputs":[{"name":"owner","type":"address"},{"name":"spender","type":"address"}],"name":"allowance","outputs":[{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"},
+	{"inputs":[{"name":"owner","type":"address"},{"name":"operator","type":"address"}],"name":"isApprovedForAll","outputs":[{"name":"","type":"bool"}],"stateMutability":"view","type":"function"},
+	{"inputs":[{"name":"interfaceId","type":"bytes4"}],"name":"supportsInterface","outputs":[{"name":"","type":"bool"}],"stateMutability":"view","type":"function"}
+]`
+
+// tokenBytes32ABI is for old tokens (e.g. MKR) returning name and symbol as bytes32
+const tokenBytes32ABI = `[
+	{"inputs":[],"name":"name","outputs":[{"name":"","type":"bytes32"}],"stateMutability":"view","type":"function"},
+	{"inputs":[],"name":"symbol","outputs":[{"name":"","type":"bytes32"}],"stateMutability":"view","type":"function"}
+]`
+
+// erc1155BalanceABI is balanceOf of ERC-1155, kept apart because it overloads ERC-20 balanceOf
+const erc1155BalanceABI = `[
+	{"inputs":[{"name":"account","type":"address"},{"name":"id","type":"uint256"}],"name":"balanceOf","outputs":[{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"}
+]`
+
+// tokenCaller calls token methods with typed arguments at a block
+type tokenCaller struct {
+	eclient  *ethclient.Client
+	contract ethereum.Address
+	bn       *big.Int
+	// custom tells eclient is a custom node client, closed with the caller
+	custom bool
+}
+
+// close closes client of a custom node
+func (t *tokenCaller) close() {
+	if t.custom {
+		t.eclient.Close()
+	}
+}
+
+func (t *tokenCaller) call(contractABI, method string, args ...interface{}) ([]interface{}, error) {
+	cABI, err := abi.JSON(strings.NewReader(contractABI))
+	if err != nil {
+		return nil, err
+	}
+	caller := cc.NewContractCaller(cABI, t.eclient, t.contract)
+	return caller.Call(&bind.CallOpts{BlockNumber: t.bn}, method, args...)
+}
+
+func (t *tokenCaller) supportsInterface(id [4]byte) bool {
+	out, err := t.call(tokenABI, "supportsInterface", id)
+	if err != nil || len(out) != 1 {
+		return false
+	}
+	supported, _ := out[0].(bool)
+	return supported
+}
+
+// text returns string output of name or symbol, falling back to bytes32 output
+func (t *tokenCaller) text(method string) string {
+	if out, err := t.call(tokenABI, method); err == nil && len(out) == 1 {
+		if s, ok := out[0].(string); ok {
+			return s
+		}
+	}
+	if out, err := t.call(tokenBytes32ABI, method); err == nil && len(out) == 1 {
+		if b, ok := out[0].([32]byte); ok {
+			return strings.TrimRight(string(b[:]), "\x00")
+		}
+	}
+	return ""
+}
+
+func (t *tokenCaller) bigInt(contractABI, method string, args ...interface{}) (*big.Int, error) {
+	out, err := t.call(contractABI, method, args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(out) != 1 {
+		return nil, fmt.Errorf("unexpected output of %s", method)
+	}
+	v, ok := out[0].(*big.Int)
+	if !ok {
+		return nil, fmt.Errorf("unexpected output type of %s", method)
+	}
+	return v, nil
+}
+
+func (t *tokenCaller) decimals() (uint8, bool) {
+	out, err := t.call(tokenABI, "decimals")
+	if err != nil || len(out) != 1 {
+		return 0, false
+	}
+	d, ok := out[0].(uint8)
+	return d, ok
+}
+
+// standard detects token standard with ERC-165 first, then by probing ERC-20 methods
+func (t *tokenCaller) standard() string {
+	if t.supportsInterface(erc165InterfaceID) && !t.supportsInterface(invalidInterfaceID) {
+		switch {
+		case t.supportsInterface(erc721InterfaceID):
+			return common.TokenStandardERC721
+		case t.supportsInterface(erc1155InterfaceID):
+			return common.TokenStandardERC1155
+		}
+	}
+	if _, err := t.bigInt(tokenABI, "totalSupply"); err != nil {
+		return common.TokenStandardUnknown
+	}
+	if _, err := t.bigInt(tokenABI, "balanceOf", ethereum.Address{}); err != nil {
+		return common.TokenStandardUnknown
+	}
+	return common.TokenStandardERC20
+}
+
+func (c *Core) newTokenCaller(token ethereum.Address, blockNumber, customNode string) (*tokenCaller, error) {
+	bn, err := ParseBlockNumber(blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	eclient, err := c.nodeClient(customNode)
+	if err != nil {
+		return nil, err
+	}
+	t := &tokenCaller{eclient: eclient, contract: token, bn: bn, custom: eclient != c.ecli}
+	code, err := eclient.CodeAt(context.Background(), token, bn)
+	if err != nil {
+		t.close()
+		return nil, upstreamError(err, "cannot get code of contract, err=%s", err)
+	}
+	if len(code) == 0 {
+		t.close()
+		return nil, notFoundError("no code at given contract")
+	}
+	return t, nil
+}
+
+// TokenInfo detects standard of token and returns its metadata, using built-in standard abis
+func (c *Core) TokenInfo(token ethereum.Address, blockNumber, customNode string) (common.TokenInfo, error) {
+	t, err := c.newTokenCaller(token, blockNumber, customNode)
+	if err != nil {
+		return common.TokenInfo{}, err
+	}
+	defer t.close()
+	info := common.TokenInfo{
+		Address:  token.Hex(),
+		Standard: t.standard(),
+	}
+	if info.Standard == common.TokenStandardUnknown {
+		return info, nil
+	}
+	info.Name, info.Symbol = t.text("name"), t.text("symbol")
+	if info.Standard == common.TokenStandardERC20 {
+		info.Decimals, _ = t.decimals()
+	}
+	if supply, err := t.bigInt(tokenABI, "totalSupply"); err == nil {
+		info.TotalSupply = supply.String()
+		info.TotalSupplyFormatted = render.FormatUnits(supply, info.Decimals)
+	}
+	return info, nil
+}
+
+// TokenBalance returns balance of owner, id is token id of ERC-1155 tokens
+func (c *Core) TokenBalance(token, owner ethereum.Address, id string, blockNumber, customNode string) (common.TokenAmount, error) {
+	t, err := c.newTokenCaller(token, blockNumber, customNode)
+	if err != nil {
+		return common.TokenAmount{}, err
+	}
+	defer t.close()
+	amount := common.TokenAmount{Token: token.Hex(), Standard: t.standard()}
+	var raw *big.Int
+	switch amount.Standard {
+	case common.TokenStandardERC1155:
+		tokenID, ok := new(big.Int).SetString(id, 10)
+		if !ok {
+			return amount, argumentError("id", "token id is required for erc1155 balance, id=%s", id)
+		}
+		raw, err = t.bigInt(erc1155BalanceABI, "balanceOf", owner, tokenID)
+	case common.TokenStandardERC20:
+		amount.Decimals, _ = t.decimals()
+		raw, err = t.bigInt(tokenABI, "balanceOf", owner)
+	case common.TokenStandardERC721:
+		raw, err = t.bigInt(tokenABI, "balanceOf", owner)
+	default:
+		return amount, validationError("contract is not a known token standard")
+	}
+	if err != nil {
+		return amount, upstreamError(err, "cannot get balance, err=%s", err)
+	}
+	amount.Raw, amount.Formatted = raw.String(), render.FormatUnits(raw, amount.Decimals)
+	return amount, nil
+}
+
+// TokenAllowance returns ERC-20 allowance of spender over owner tokens, for ERC-721 and ERC-1155 tokens
+// it reports whether spender is approved for all tokens of owner
+func (c *Core) TokenAllowance(token, owner, spender ethereum.Address, blockNumber, customNode string) (common.TokenAmount, error) {
+	t, err := c.newTokenCaller(token, blockNumber, customNode)
+	if err != nil {
+		return common.TokenAmount{}, err
+	}
+	defer t.close()
+	amount := common.TokenAmount{Token: token.Hex(), Standard: t.standard()}
+	switch amount.Standard {
+	case common.TokenStandardERC20:
+		amount.Decimals, _ = t.decimals()
+		raw, err := t.bigInt(tokenABI, "allowance", owner, spender)
+		if err != nil {
+			return amount, upstreamError(err, "cannot get allowance, err=%s", err)
+		}
+		amount.Raw, amount.Formatted = raw.String(), render.FormatUnits(raw, amount.Decimals)
+	case common.TokenStandardERC721, common.TokenStandardERC1155:
+		out, err := t.call(tokenABI, "isApprovedForAll", owner, spender)
+		if err != nil {
+			return amount, upstreamError(err, "cannot get approval, err=%s", err)
+		}
+		if len(out) != 1 {
+			return amount, upstreamError(fmt.Errorf("unexpected output, length=%d", len(out)),
+				"cannot get approval, unexpected output length=%d", len(out))
+		}
+		approved, _ := out[0].(bool)
+		amount.ApprovedForAll = &approved
+	default:
+		return amount, validationError("contract is not a known token standard")
+	}
+	return amount, nil
+}
@@ -0,0 +1,22 @@
+package core
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenABIs(t *testing.T) {
+	for _, raw := range []string{tokenABI, tokenBytes32ABI, erc1155BalanceABI} {
+		_, err := abi.JSON(strings.NewReader(raw))
+		require.NoError(t, err)
+	}
+	cABI, err := abi.JSON(strings.NewReader(tokenABI))
+	require.NoError(t, err)
+	data, err := cABI.Pack("supportsInterface", erc721InterfaceID)
+	require.NoError(t, err)
+	require.Equal(t, "0x01ffc9a780ac58cd00000000000000000000000000000000000000000000000000000000", hexutil.Encode(data))
+}
@@ -0,0 +1,116 @@
+package core
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"github.com/KyberNetwork/contract-caller/common"
+)
+
+// watchPollInterval is how often a node without subscription support is polled for new blocks
+const watchPollInterval = 5 * time.Second
+
+// WatchContract calls method on every new block and sends an update whenever the result changes.
+// It subscribes to new heads when the node supports it (websocket), otherwise or once the subscription fails it
+// polls, websocket nodes are redialed by the next request. The returned channel is closed when ctx is done.
+func (c *Core) WatchContract(
+	ctx context.Context,
+	contract ethereum.Address,
+	contractABI, network, methodName string,
+	params map[string]interface{},
+	customNode string) (<-chan common.WatchUpdate, error) {
+
+	l := c.l.With("func", "core/WatchContract", "contract", contract.Hex(), "method", methodName)
+	if len(contractABI) == 0 {
+		rawABI, err := c.ContractABI(contract, network)
+		if err != nil {
+			return nil, err
+		}
+		contractABI = rawABI
+	}
+	eclient, err := c.nodeClient(customNode)
+	if err != nil {
+		return nil, err
+	}
+	heads := make(chan *types.Header)
+	sub, err := eclient.SubscribeNewHead(ctx, heads)
+	if err != nil {
+		l.Infow("node does not support subscription, fall back to polling", "err", err)
+		go c.pollNewHead(ctx, eclient, heads)
+	}
+
+	updates := make(chan common.WatchUpdate)
+	go func() {
+		defer close(updates)
+		if eclient != c.ecli {
+			defer eclient.Close()
+		}
+		var (
+			last    *common.WatchUpdate
+			subErrC <-chan error
+		)
+		if sub != nil {
+			defer sub.Unsubscribe()
+			subErrC = sub.Err()
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case err := <-subErrC:
+				l.Warnw("subscription failed, fall back to polling", "err", err)
+				sub.Unsubscribe()
+				subErrC = nil
+				go c.pollNewHead(ctx, eclient, heads)
+			case head := <-heads:
+				u := common.WatchUpdate{BlockNumber: head.Number.Uint64()}
+				result, err := c.callContract(eclient, contract, contractABI, methodName, head.Number, params)
+				if err != nil {
+					u.Err = err.Error()
+				}
+				u.Result = result
+				if last != nil && last.Err == u.Err && reflect.DeepEqual(last.Result, u.Result) {
+					continue
+				}
+				last = &u
+				select {
+				case updates <- u:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return updates, nil
+}
+
+// pollNewHead sends latest header to heads whenever block number increases
+func (c *Core) pollNewHead(ctx context.Context, eclient *ethclient.Client, heads chan<- *types.Header) {
+	l := c.l.With("func", "core/pollNewHead")
+	ticker := time.NewTicker(watchPollInterval)
+	defer ticker.Stop()
+	var lastNumber uint64
+	for {
+		head, err := eclient.HeaderByNumber(ctx, nil)
+		if err != nil {
+			l.Errorw("cannot get latest header", "err", err)
+		} else if head.Number.Uint64() > lastNumber {
+			lastNumber = head.Number.Uint64()
+			select {
+			case heads <- head:
+			case <-ctx.Done():
+				return
+			}
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -5,6 +5,8 @@ import React from "react";
 // const baseURL = `${process.env.SERVER_URL}/contract`
 const apiURL = process.env.REACT_APP_API_URL ? process.env.REACT_APP_API_URL : 'http://localhost:3001'
 const baseURL = `${apiURL}/contract`
+// apiKeyStorage keeps the api key typed by the user for the browser session, keys are never built into the bundle
+const apiKeyStorage = 'contractCallerApiKey'
 export default class App extends React.Component {
   constructor(props) {
     super(props);
@@ -12,6 +14,7 @@ export default class App extends React.Component {
       contract: '',
       abi: '',
       methods: null,
+      contractName: '',
       init: true,
       error: '',
       selectedMethod: '',
@@ -21,15 +24,32 @@ export default class App extends React.Component {
       rememberABI: false,
       networkEndpoint: '',
       network: '',
-      isChangingNetwork: false
+      isChangingNetwork: false,
+      apiKey: window.sessionStorage.getItem(apiKeyStorage) || ''
     };
   }
 
+  // apiHeaders authenticate requests when the user gave an api key
+  apiHeaders() {
+    return this.state.apiKey ? {'X-API-Key': this.state.apiKey} : {}
+  }
+
+  handleChangeApiKey(e) {
+    const apiKey = e.target.value.trim()
+    if (apiKey) {
+      window.sessionStorage.setItem(apiKeyStorage, apiKey)
+    } else {
+      window.sessionStorage.removeItem(apiKeyStorage)
+    }
+    this.setState({apiKey: apiKey})
+  }
+
   componentDidMount() {
     console.log(baseURL)
     var url = `${baseURL}/network-info`
     fetch(url, {
       method: 'GET',
+      headers: this.apiHeaders(),
     }).then(response => response.json()).then(data => {
       if (data.err) {
         this.setError(data.err)
@@ -37,6 +57,29 @@ export default class App extends React.Component {
       }
       this.setState({network: data.data})
     }).catch(err => this.setState({error: 'cannot get network info'}))
+    const queryID = new URLSearchParams(window.location.search).get('query')
+    if (queryID) {
+      this.openQuery(queryID)
+    }
+  }
+
+  // openQuery prefills the form from a saved query permalink and re-runs the call
+  openQuery(id) {
+    fetch(`${apiURL}/query/${id}`, {
+      method: 'GET',
+      headers: this.apiHeaders(),
+    }).then(response => response.json()).then(data => {
+      if (data.err) {
+        this.setError(data.err)
+        return
+      }
+      const query = data.data
+      this.setState({contract: query.contract, blockNumber: query.blockNumber}, () => {
+        this.verifyAndAccessContract(() => {
+          this.setState({selectedMethod: query.method, callData: query.params || {}}, () => this.submitContractData())
+        })
+      })
+    }).catch(err => this.setError(err))
   }
 
   handleChangeContract(e) {
@@ -58,6 +101,7 @@ export default class App extends React.Component {
       const url = `${baseURL}/network-info?node=${input}`
       fetch(url, {
         method: 'GET',
+        headers: this.apiHeaders(),
       }).then(response => response.json()).then(data => {
         if (data.err) {
           this.setError(data.err)
@@ -85,6 +129,10 @@ export default class App extends React.Component {
               <input onFocus={(e) => this.setError('')} id="network-input" placeholder="To keep the old one, leave it empty and press done" type="text"/>
             </div>) : ''}
         </div>
+        <div className="contract contract-address">
+          <div className="label">API Key</div>
+          <input onFocus={(e) => {this.setError('')}} onChange={(e) => {this.handleChangeApiKey(e)}} value={this.state.apiKey} placeholder="Only needed when the server requires one" type="password"/>
+        </div>
         <div className="contract contract-address">
           <div className="label">Contract Address</div>
           <input onFocus={(e) => {this.setError('')}} onChange={(e) => {this.handleChangeContract(e)}} value={this.state.contract} type="text"/>
@@ -105,7 +153,7 @@ export default class App extends React.Component {
     )
   }
 
-  verifyAndAccessContract() {
+  verifyAndAccessContract(onLoaded) {
     this.setError('')
     var data = {
       contract: this.state.contract,
@@ -117,6 +165,7 @@ export default class App extends React.Component {
     fetch(url, {
       method: 'POST',
       headers: {
+        ...this.apiHeaders(),
         'Content-Type': 'application/json',
       },
       body: JSON.stringify(data)
@@ -126,7 +175,8 @@ export default class App extends React.Component {
         return
       }
       if (Array.isArray(data.data) && data.data.length > 0) {
-        this.setState({methods: data.data, selectedMethod: data.data[0].name, init: false})
+        const contractName = data.contract ? data.contract.name : ''
+        this.setState({methods: data.data, contractName: contractName, selectedMethod: data.data[0].name, init: false}, onLoaded)
       } else {
         this.setError("cannot get data from server")
         return
@@ -190,6 +240,7 @@ export default class App extends React.Component {
     fetch(url, {
       method: 'POST',
       headers: {
+        ...this.apiHeaders(),
         'Content-Type': 'application/json',
       },
       body: JSON.stringify(data)
@@ -213,6 +264,7 @@ export default class App extends React.Component {
       if (method.name == this.state.selectedMethod) {
         return (
           <div key={index}>
+            {method.doc ? <div className="param-doc">{method.doc}</div> : ''}
             {Array.isArray(method.arguments) ? this.generateArgs(method.arguments) : ''}
           </div>
         )
@@ -221,7 +273,7 @@ export default class App extends React.Component {
     return (
       <div className="container container-call">
         <div className="contract contract-method">
-          <div className="label">Methods</div>
+          <div className="label">{this.state.contractName ? `Methods of ${this.state.contractName}` : 'Methods'}</div>
           <select 
             value={this.state.selectedMethod} 
             onChange={this.handleSelectChange} 
@@ -269,6 +321,7 @@ export default class App extends React.Component {
       contract: '',
       abi: '',
       methods: null,
+      contractName: '',
       init: true,
       error: '',
       selectedMethod: '',
@@ -0,0 +1,62 @@
+package etherscan
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrorKind tells why an etherscan request failed
+type ErrorKind string
+
+const (
+	// KindNotVerified is for contracts without verified source code
+	KindNotVerified ErrorKind = "not_verified"
+	// KindRateLimited is for requests rejected by rate limit of the api key
+	KindRateLimited ErrorKind = "rate_limited"
+	// KindInvalidKey is for missing or invalid api key
+	KindInvalidKey ErrorKind = "invalid_key"
+	// KindNetwork is for requests which could not reach etherscan or got an unexpected http response
+	KindNetwork ErrorKind = "network"
+	// KindAPI is for other errors returned by etherscan
+	KindAPI ErrorKind = "api"
+)
+
+// Error is an error of etherscan api, Err is the request failure if any
+type Error struct {
+	Kind    ErrorKind
+	Message string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	return string(e.Kind) + ": " + e.Message
+}
+
+// Unwrap returns the request failure, so deadlines and network errors can be told apart
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// IsKind reports whether err is an etherscan error of given kind
+func IsKind(err error, kind ErrorKind) bool {
+	var e *Error
+	return errors.As(err, &e) && e.Kind == kind
+}
+
+// responseError classifies a failed response, etherscan puts the reason in result with message NOTOK
+func responseError(message, result string) *Error {
+	reason := result
+	if reason == "" {
+		reason = message
+	}
+	lower := strings.ToLower(reason)
+	switch {
+	case strings.Contains(lower, "not verified"):
+		return &Error{Kind: KindNotVerified, Message: reason}
+	case strings.Contains(lower, "rate limit"):
+		return &Error{Kind: KindRateLimited, Message: reason}
+	case strings.Contains(lower, "api key"):
+		return &Error{Kind: KindInvalidKey, Message: reason}
+	}
+	return &Error{Kind: KindAPI, Message: reason}
+}
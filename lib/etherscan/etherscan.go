@@ -1,65 +1,241 @@
 package etherscan
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
+	"sync"
+	"time"
 
 	ethereum "github.com/ethereum/go-ethereum/common"
+	"golang.org/x/time/rate"
 
 	"github.com/KyberNetwork/contract-caller/common"
 	libhttp "github.com/KyberNetwork/contract-caller/lib/http"
 )
 
-// Etherscan ...
+const (
+	// requestsPerSecond is rate limit of free tier api keys
+	requestsPerSecond = 5
+	// maxRetries is number of retries of rate limited, 5xx and network failures
+	maxRetries = 3
+	// retryBackoff is wait before the first retry, doubled on each retry
+	retryBackoff = 500 * time.Millisecond
+	// notVerifiedTTL is how long a contract without verified source is not looked up again
+	notVerifiedTTL = 10 * time.Minute
+)
+
+var (
+	limitersMu sync.Mutex
+	// limiters are shared by clients using the same api key
+	limiters = make(map[string]*rate.Limiter)
+)
+
+// keyLimiter returns token bucket limiter of an api key
+func keyLimiter(apiKey string) *rate.Limiter {
+	limitersMu.Lock()
+	defer limitersMu.Unlock()
+	l, ok := limiters[apiKey]
+	if !ok {
+		l = rate.NewLimiter(rate.Limit(requestsPerSecond), requestsPerSecond)
+		limiters[apiKey] = l
+	}
+	return l
+}
+
+// Config is explorer api of a chain, empty base url and flavor are taken from the network registry
+type Config struct {
+	ChainID int64  `json:"chainId" yaml:"chainId"`
+	APIKey  string `json:"apiKey" yaml:"apiKey"`
+	BaseURL string `json:"baseUrl" yaml:"baseUrl"`
+	Flavor  string `json:"flavor" yaml:"flavor"`
+}
+
+// url returns api url of an action on a contract, base url may already end with /api (Blockscout instances)
+func (cfg Config) url(action string, contractAddress ethereum.Address) string {
+	base := strings.TrimSuffix(cfg.BaseURL, "/")
+	if !strings.HasSuffix(base, "/api") {
+		base += "/api"
+	}
+	url := fmt.Sprintf("%s?module=contract&action=%s&address=%s", base, action, contractAddress.Hex())
+	if cfg.APIKey != "" {
+		url += "&apikey=" + cfg.APIKey
+	}
+	return url
+}
+
+// limiterKey is api key, or base url for requests without key
+func (cfg Config) limiterKey() string {
+	if cfg.APIKey != "" {
+		return cfg.APIKey
+	}
+	return cfg.BaseURL
+}
+
+// Etherscan is client of explorer apis, configured per chain id
 type Etherscan struct {
 	apiKey  string
 	baseAPI string
+	configs map[int64]Config
 	cli     *libhttp.RestClient
+	backoff time.Duration
+
+	mu sync.Mutex
+	// notVerified is expiry of negative cache entries by network and contract
+	notVerified map[string]time.Time
 }
 
-// NewEtherscan ...
-func NewEtherscan(apiKey string) *Etherscan {
-	return &Etherscan{
-		apiKey:  apiKey,
-		baseAPI: "https://api.etherscan.io",
-		cli:     libhttp.NewRestClient(&http.Client{}),
+// NewEtherscan returns explorer client, apiKey is used for etherscan.io networks without a configured key
+func NewEtherscan(apiKey string, configs ...Config) *Etherscan {
+	e := &Etherscan{
+		apiKey:      apiKey,
+		baseAPI:     "https://api.etherscan.io",
+		configs:     make(map[int64]Config),
+		cli:         libhttp.NewRestClient(nil),
+		backoff:     retryBackoff,
+		notVerified: make(map[string]time.Time),
+	}
+	for _, cfg := range configs {
+		e.configs[cfg.ChainID] = cfg
 	}
+	return e
 }
 
 type etherscanResponse struct {
-	Status  string `json:"status"`
-	Message string `json:"message"`
-	Result  string `json:"result"`
+	Status  string          `json:"status"`
+	Message string          `json:"message"`
+	Result  json.RawMessage `json:"result"`
 }
 
-func (e *Etherscan) baseAPIURLFromNetwork(network string) string {
-	switch network {
-	case common.EthereumMainnet:
-		return "https://api.etherscan.io"
-	case common.EthereumRopsten:
-		return "https://api-ropsten.etherscan.io"
-	case common.EthereumKovan:
-		return "https://api-kovan.etherscan.io"
-	case common.BSCMainnet:
-		return "https://api.bscscan.com"
-	case common.BSCTestnet:
-		return "https://api-testnet.bscscan.com"
-	default:
-		return e.baseAPI
+// explorer returns explorer config of network, unknown networks use Etherscan mainnet
+func (e *Etherscan) explorer(network string) Config {
+	n, ok := common.NetworkByName(network)
+	if !ok {
+		return Config{APIKey: e.apiKey, BaseURL: e.baseAPI, Flavor: common.ExplorerEtherscan}
+	}
+	cfg := e.configs[n.ChainID]
+	cfg.ChainID = n.ChainID
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = n.ExplorerAPI
+	}
+	if cfg.Flavor == "" {
+		cfg.Flavor = n.ExplorerFlavor
 	}
+	if cfg.APIKey == "" && strings.Contains(cfg.BaseURL, "etherscan.io") {
+		cfg.APIKey = e.apiKey
+	}
+	return cfg
+}
+
+// get sends a request to etherscan api under rate limit of the api key, retrying with backoff when the request
+// is rate limited, gets a 5xx response or cannot reach etherscan, until ctx is done
+func (e *Etherscan) get(ctx context.Context, cfg Config, url string) (etherscanResponse, error) {
+	limiter := keyLimiter(cfg.limiterKey())
+	var err error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return etherscanResponse{}, &Error{Kind: KindNetwork, Message: ctx.Err().Error(), Err: ctx.Err()}
+			case <-time.After(e.backoff << uint(attempt-1)):
+			}
+		}
+		if waitErr := limiter.Wait(ctx); waitErr != nil {
+			cause := ctx.Err()
+			if cause == nil {
+				// waiting for the limiter would exceed deadline of ctx
+				cause = context.DeadlineExceeded
+			}
+			return etherscanResponse{}, &Error{Kind: KindNetwork, Message: waitErr.Error(), Err: cause}
+		}
+		var resp etherscanResponse
+		if reqErr := e.cli.Do(ctx, libhttp.Request{Method: http.MethodGet, URL: url}, &resp); reqErr != nil {
+			if libhttp.StatusCode(reqErr) == http.StatusTooManyRequests {
+				err = &Error{Kind: KindRateLimited, Message: reqErr.Error(), Err: reqErr}
+				continue
+			}
+			err = &Error{Kind: KindNetwork, Message: reqErr.Error(), Err: reqErr}
+			if !retryable(ctx, reqErr) {
+				return etherscanResponse{}, err
+			}
+			continue
+		}
+		if resp.Status == "1" {
+			return resp, nil
+		}
+		var result string
+		_ = json.Unmarshal(resp.Result, &result)
+		respErr := responseError(resp.Message, result)
+		if respErr.Kind != KindRateLimited {
+			return etherscanResponse{}, respErr
+		}
+		err = respErr
+	}
+	return etherscanResponse{}, err
+}
+
+// retryable tells whether a failed request may succeed when sent again: 5xx responses and failures to reach
+// etherscan are, other responses, undecodable bodies and requests whose ctx is done are not
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if code := libhttp.StatusCode(err); code != 0 {
+		return code >= http.StatusInternalServerError
+	}
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+	)
+	return err != libhttp.ErrBodyTooLarge && !errors.As(err, &syntaxErr) && !errors.As(err, &typeErr)
+}
+
+func notVerifiedKey(contractAddress ethereum.Address, network string) string {
+	return network + "/" + contractAddress.Hex()
+}
+
+// cachedNotVerified returns a not verified error if contract was found not verified within ttl
+func (e *Etherscan) cachedNotVerified(contractAddress ethereum.Address, network string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	key := notVerifiedKey(contractAddress, network)
+	expiry, ok := e.notVerified[key]
+	if !ok {
+		return nil
+	}
+	if time.Now().After(expiry) {
+		delete(e.notVerified, key)
+		return nil
+	}
+	return &Error{Kind: KindNotVerified, Message: notVerifiedABI}
+}
+
+// remember keeps not verified contracts in negative cache
+func (e *Etherscan) remember(contractAddress ethereum.Address, network string, err error) error {
+	if IsKind(err, KindNotVerified) {
+		e.mu.Lock()
+		e.notVerified[notVerifiedKey(contractAddress, network)] = time.Now().Add(notVerifiedTTL)
+		e.mu.Unlock()
+	}
+	return err
 }
 
 // GetContractABI ...
-func (e *Etherscan) GetContractABI(contractAddress ethereum.Address, network string) (string, error) {
-	url := fmt.Sprintf("%s/api?module=contract&action=getabi&address=%s&apikey=%s",
-		e.baseAPIURLFromNetwork(network), contractAddress.Hex(), e.apiKey)
-	fmt.Printf("\n\n\n %s", e.baseAPIURLFromNetwork(network))
-	var resp etherscanResponse
-	if err := e.cli.DoReq(url, http.MethodGet, nil, &resp); err != nil {
+func (e *Etherscan) GetContractABI(ctx context.Context, contractAddress ethereum.Address, network string) (string, error) {
+	if err := e.cachedNotVerified(contractAddress, network); err != nil {
 		return "", err
 	}
-	if resp.Status != "1" {
-		return "", fmt.Errorf("error msg: %s", resp.Message)
+	cfg := e.explorer(network)
+	resp, err := e.get(ctx, cfg, cfg.url("getabi", contractAddress))
+	if err != nil {
+		return "", e.remember(contractAddress, network, err)
+	}
+	var result string
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		return "", err
 	}
-	return resp.Result, nil
+	return result, nil
 }
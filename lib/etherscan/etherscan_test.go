@@ -0,0 +1,146 @@
+package etherscan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KyberNetwork/contract-caller/common"
+)
+
+func newTestEtherscan(handler http.HandlerFunc) (*Etherscan, *httptest.Server) {
+	server := httptest.NewServer(handler)
+	e := NewEtherscan("test-key")
+	e.baseAPI, e.backoff = server.URL, time.Millisecond
+	return e, server
+}
+
+func TestGetContractABIRetry(t *testing.T) {
+	requests := 0
+	e, server := newTestEtherscan(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			fmt.Fprint(w, `{"status":"0","message":"NOTOK","result":"Max rate limit reached"}`)
+			return
+		}
+		fmt.Fprint(w, `{"status":"1","message":"OK","result":"[]"}`)
+	})
+	defer server.Close()
+
+	abi, err := e.GetContractABI(context.Background(), ethereum.HexToAddress("0x01"), "")
+	require.NoError(t, err)
+	require.Equal(t, "[]", abi)
+	require.Equal(t, 2, requests)
+}
+
+func TestGetContractABIErrors(t *testing.T) {
+	requests := 0
+	result := "Contract source code not verified"
+	e, server := newTestEtherscan(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprintf(w, `{"status":"0","message":"NOTOK","result":"%s"}`, result)
+	})
+	defer server.Close()
+
+	contract := ethereum.HexToAddress("0x01")
+	_, err := e.GetContractABI(context.Background(), contract, "")
+	require.True(t, IsKind(err, KindNotVerified))
+	// not verified contracts are cached
+	_, err = e.GetSourceCode(context.Background(), contract, "")
+	require.True(t, IsKind(err, KindNotVerified))
+	require.Equal(t, 1, requests)
+
+	result = "Invalid API Key"
+	_, err = e.GetContractABI(context.Background(), ethereum.HexToAddress("0x02"), "")
+	require.True(t, IsKind(err, KindInvalidKey))
+
+	requests, result = 0, "Max rate limit reached"
+	_, err = e.GetContractABI(context.Background(), ethereum.HexToAddress("0x02"), "")
+	require.True(t, IsKind(err, KindRateLimited))
+	require.Equal(t, maxRetries+1, requests)
+}
+
+func TestGetContractABINetworkError(t *testing.T) {
+	e, server := newTestEtherscan(func(w http.ResponseWriter, r *http.Request) {})
+	server.Close()
+
+	_, err := e.GetContractABI(context.Background(), ethereum.HexToAddress("0x01"), "")
+	require.True(t, IsKind(err, KindNetwork))
+}
+
+func TestGetContractABIRetryStatus(t *testing.T) {
+	requests, status := 0, http.StatusBadGateway
+	e, server := newTestEtherscan(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(status)
+	})
+	defer server.Close()
+
+	_, err := e.GetContractABI(context.Background(), ethereum.HexToAddress("0x01"), "")
+	require.True(t, IsKind(err, KindNetwork))
+	require.Equal(t, maxRetries+1, requests)
+
+	// other responses are not retried
+	requests, status = 0, http.StatusForbidden
+	_, err = e.GetContractABI(context.Background(), ethereum.HexToAddress("0x01"), "")
+	require.True(t, IsKind(err, KindNetwork))
+	require.Equal(t, 1, requests)
+}
+
+func TestGetContractABIDeadline(t *testing.T) {
+	requests := 0
+	e, server := newTestEtherscan(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		time.Sleep(200 * time.Millisecond)
+	})
+	defer server.Close()
+	// a key of its own, so the limiter does not wait for requests of other tests
+	e.apiKey = "deadline-key"
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := e.GetContractABI(ctx, ethereum.HexToAddress("0x01"), "")
+	require.True(t, IsKind(err, KindNetwork))
+	require.True(t, errors.Is(err, context.DeadlineExceeded))
+	require.Equal(t, 1, requests)
+}
+
+func TestExplorer(t *testing.T) {
+	e := NewEtherscan("etherscan-key",
+		Config{ChainID: 56, APIKey: "bscscan-key"},
+		Config{ChainID: 137, BaseURL: "https://blockscout.example.com/poly/api/", Flavor: common.ExplorerBlockscout},
+	)
+	contract := ethereum.HexToAddress("0x01")
+
+	cfg := e.explorer(common.EthereumMainnet)
+	require.Equal(t, "https://api.etherscan.io/api?module=contract&action=getabi&address="+contract.Hex()+
+		"&apikey=etherscan-key", cfg.url("getabi", contract))
+	cfg = e.explorer(common.BSCMainnet)
+	require.Equal(t, "https://api.bscscan.com/api?module=contract&action=getabi&address="+contract.Hex()+
+		"&apikey=bscscan-key", cfg.url("getabi", contract))
+	cfg = e.explorer(common.PolygonMainnet)
+	require.Equal(t, common.ExplorerBlockscout, cfg.Flavor)
+	require.Equal(t, "https://blockscout.example.com/poly/api?module=contract&action=getsourcecode&address="+
+		contract.Hex(), cfg.url("getsourcecode", contract))
+	// etherscan key is not sent to other explorers
+	require.Empty(t, e.explorer(common.ArbitrumMainnet).APIKey)
+}
+
+func TestBlockscoutSource(t *testing.T) {
+	source := toContractSource(common.ExplorerBlockscout, ethereum.HexToAddress("0x01"), sourceCodeResult{
+		ContractName:          "Proxy",
+		OptimizationUsed:      "true",
+		IsProxy:               "true",
+		ImplementationAddress: "0x0000000000000000000000000000000000000002",
+	})
+	require.True(t, source.OptimizationUsed)
+	require.True(t, source.Proxy)
+	require.Equal(t, "0x0000000000000000000000000000000000000002", source.Implementation)
+}
@@ -0,0 +1,163 @@
+package etherscan
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	ethereum "github.com/ethereum/go-ethereum/common"
+
+	"github.com/KyberNetwork/contract-caller/common"
+)
+
+// notVerifiedABI is abi returned by getsourcecode for contracts without verified source
+const notVerifiedABI = "Contract source code not verified"
+
+type sourceCodeResult struct {
+	SourceCode       string `json:"SourceCode"`
+	ABI              string `json:"ABI"`
+	ContractName     string `json:"ContractName"`
+	CompilerVersion  string `json:"CompilerVersion"`
+	OptimizationUsed string `json:"OptimizationUsed"`
+	Runs             string `json:"Runs"`
+	EVMVersion       string `json:"EVMVersion"`
+	LicenseType      string `json:"LicenseType"`
+	Proxy            string `json:"Proxy"`
+	Implementation   string `json:"Implementation"`
+	// Blockscout names proxy fields differently
+	IsProxy               string `json:"IsProxy"`
+	ImplementationAddress string `json:"ImplementationAddress"`
+}
+
+// GetSourceCode returns metadata, abi and function docs of a verified contract
+func (e *Etherscan) GetSourceCode(ctx context.Context, contractAddress ethereum.Address, network string) (common.ContractSource, error) {
+	if err := e.cachedNotVerified(contractAddress, network); err != nil {
+		return common.ContractSource{}, err
+	}
+	cfg := e.explorer(network)
+	resp, err := e.get(ctx, cfg, cfg.url("getsourcecode", contractAddress))
+	if err != nil {
+		return common.ContractSource{}, e.remember(contractAddress, network, err)
+	}
+	var results []sourceCodeResult
+	if err := json.Unmarshal(resp.Result, &results); err != nil {
+		return common.ContractSource{}, err
+	}
+	if len(results) == 0 || results[0].ABI == notVerifiedABI {
+		return common.ContractSource{}, e.remember(contractAddress, network,
+			&Error{Kind: KindNotVerified, Message: notVerifiedABI})
+	}
+	return toContractSource(cfg.Flavor, contractAddress, results[0]), nil
+}
+
+func toContractSource(flavor string, contractAddress ethereum.Address, r sourceCodeResult) common.ContractSource {
+	runs, _ := strconv.Atoi(r.Runs)
+	source := common.ContractSource{
+		Address:          contractAddress.Hex(),
+		Name:             r.ContractName,
+		CompilerVersion:  r.CompilerVersion,
+		OptimizationUsed: r.OptimizationUsed == "1",
+		Runs:             runs,
+		EVMVersion:       r.EVMVersion,
+		License:          r.LicenseType,
+		Proxy:            r.Proxy == "1",
+		Docs:             FunctionDocs(sourceFiles(r.SourceCode)),
+		ABI:              r.ABI,
+	}
+	implementation := r.Implementation
+	if flavor == common.ExplorerBlockscout {
+		source.OptimizationUsed = r.OptimizationUsed == "true" || r.OptimizationUsed == "1"
+		source.Proxy = r.IsProxy == "true"
+		implementation = r.ImplementationAddress
+	}
+	if ethereum.IsHexAddress(implementation) {
+		source.Implementation = ethereum.HexToAddress(implementation).Hex()
+	}
+	return source
+}
+
+// sourceFiles returns contents of source files, SourceCode is either a flattened file, a json of
+// {"file": {"content": ...}} or a solc standard json input wrapped in double braces
+func sourceFiles(sourceCode string) []string {
+	trimmed := strings.TrimSpace(sourceCode)
+	if !strings.HasPrefix(trimmed, "{") {
+		return []string{sourceCode}
+	}
+	type file struct {
+		Content string `json:"content"`
+	}
+	var files map[string]file
+	if strings.HasPrefix(trimmed, "{{") {
+		var input struct {
+			Sources map[string]file `json:"sources"`
+		}
+		if err := json.Unmarshal([]byte(trimmed[1:len(trimmed)-1]), &input); err != nil {
+			return []string{sourceCode}
+		}
+		files = input.Sources
+	} else if err := json.Unmarshal([]byte(trimmed), &files); err != nil {
+		return []string{sourceCode}
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	contents := make([]string, 0, len(files))
+	for _, name := range names {
+		contents = append(contents, files[name].Content)
+	}
+	return contents
+}
+
+// natspecFunction matches a /// or /** */ comment followed by a function declaration
+var natspecFunction = regexp.MustCompile(`((?:[ \t]*///[^\n]*\n)+|/\*\*(?:[^*]|\*+[^*/])*\*+/)\s*function\s+(\w+)`)
+
+// FunctionDocs extracts NatSpec of functions by name, @notice is preferred over @dev.
+// Docs of the first declaration are kept when a function is declared more than once.
+func FunctionDocs(sources []string) map[string]string {
+	docs := make(map[string]string)
+	for _, source := range sources {
+		for _, m := range natspecFunction.FindAllStringSubmatch(source, -1) {
+			if _, ok := docs[m[2]]; ok {
+				continue
+			}
+			if doc := natspecDoc(m[1]); doc != "" {
+				docs[m[2]] = doc
+			}
+		}
+	}
+	return docs
+}
+
+// natspecDoc returns text of @notice (or untagged text), falling back to @dev
+func natspecDoc(comment string) string {
+	var (
+		tags    = make(map[string][]string)
+		current = "notice"
+	)
+	for _, line := range strings.Split(comment, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimPrefix(line, "///")
+		line = strings.TrimPrefix(line, "/**")
+		line = strings.TrimSuffix(line, "*/")
+		line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "*"))
+		if strings.HasPrefix(line, "@") {
+			parts := strings.SplitN(line, " ", 2)
+			current, line = strings.TrimPrefix(parts[0], "@"), ""
+			if len(parts) == 2 {
+				line = strings.TrimSpace(parts[1])
+			}
+		}
+		if line != "" {
+			tags[current] = append(tags[current], line)
+		}
+	}
+	if notice := tags["notice"]; len(notice) != 0 {
+		return strings.Join(notice, " ")
+	}
+	return strings.Join(tags["dev"], " ")
+}
@@ -0,0 +1,45 @@
+package etherscan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testSource = `pragma solidity 0.6.6;
+
+contract Token {
+    /// @notice Returns balance of owner
+    /// @param owner holder of tokens
+    function balanceOf(address owner) external view returns (uint256) {}
+
+    /**
+     * @dev Moves tokens,
+     * emits a Transfer event.
+     */
+    function transfer(address to, uint256 value) external returns (bool) {}
+
+    /* not natspec */
+    function totalSupply() external view returns (uint256) {}
+
+    /// Untagged text is a notice
+    function name() external view returns (string memory) {}
+}
+`
+
+func TestFunctionDocs(t *testing.T) {
+	docs := FunctionDocs([]string{testSource})
+	require.Equal(t, map[string]string{
+		"balanceOf": "Returns balance of owner",
+		"transfer":  "Moves tokens, emits a Transfer event.",
+		"name":      "Untagged text is a notice",
+	}, docs)
+}
+
+func TestSourceFiles(t *testing.T) {
+	require.Equal(t, []string{"contract A {}"}, sourceFiles("contract A {}"))
+	require.Equal(t, []string{"contract A {}", "contract B {}"},
+		sourceFiles(`{"b.sol": {"content": "contract B {}"}, "a.sol": {"content": "contract A {}"}}`))
+	require.Equal(t, []string{"contract A {}"},
+		sourceFiles(`{{"language": "Solidity", "sources": {"a.sol": {"content": "contract A {}"}}}}`))
+}
@@ -2,71 +2,227 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	stderrors "errors"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 )
 
+const (
+	// DefaultTimeout is timeout of clients created without one
+	DefaultTimeout = 30 * time.Second
+	// DefaultMaxBodySize is the largest response body read by default
+	DefaultMaxBodySize = 10 << 20
+	// maxErrorBody is the largest part of body put in error messages
+	maxErrorBody = 512
+)
+
+// ErrBodyTooLarge is returned when response body is larger than max body size
+var ErrBodyTooLarge = errors.New("response body too large")
+
+// HTTPError is a response with a non 2xx status code, the raw body is kept as is
+type HTTPError struct {
+	StatusCode int
+	Body       []byte
+}
+
+func (e *HTTPError) Error() string {
+	body := string(e.Body)
+	if len(body) > maxErrorBody {
+		body = body[:maxErrorBody] + "..."
+	}
+	return fmt.Sprintf("receive unexpected code, actual code: %d, body: %s", e.StatusCode, body)
+}
+
+// StatusCode returns status code of a HTTPError, 0 for other errors
+func StatusCode(err error) int {
+	var httpErr *HTTPError
+	if stderrors.As(err, &httpErr) {
+		return httpErr.StatusCode
+	}
+	return 0
+}
+
+// RetryFunc is called after failed attempt (starting at 1) and returns whether to retry and how long to wait before
+type RetryFunc func(attempt int, err error) (time.Duration, bool)
+
+// RetryTransient retries network errors, 429 and 5xx responses up to maxRetries times, doubling backoff each time
+func RetryTransient(maxRetries int, backoff time.Duration) RetryFunc {
+	return func(attempt int, err error) (time.Duration, bool) {
+		if attempt > maxRetries || err == ErrBodyTooLarge {
+			return 0, false
+		}
+		if code := StatusCode(err); code != 0 && code != http.StatusTooManyRequests && code < http.StatusInternalServerError {
+			return 0, false
+		}
+		return backoff << uint(attempt-1), true
+	}
+}
+
+// Request is a request sent by RestClient, body is sent as json
+type Request struct {
+	Method string
+	URL    string
+	Query  url.Values
+	Header http.Header
+	Body   interface{}
+}
+
 // RestClient ...
 type RestClient struct {
-	c *http.Client
+	c           *http.Client
+	header      http.Header
+	maxBodySize int64
+	retry       RetryFunc
 }
 
-// NewRestClient ...
+// NewRestClient returns client using c, with DefaultTimeout when c has no timeout
 func NewRestClient(c *http.Client) *RestClient {
+	client := &http.Client{}
+	if c != nil {
+		*client = *c
+	}
+	if client.Timeout == 0 {
+		client.Timeout = DefaultTimeout
+	}
 	return &RestClient{
-		c: c,
+		c:           client,
+		header:      make(http.Header),
+		maxBodySize: DefaultMaxBodySize,
+	}
+}
+
+// WithHeader sets a header sent with every request
+func (rc *RestClient) WithHeader(key, value string) *RestClient {
+	rc.header.Set(key, value)
+	return rc
+}
+
+// WithMaxBodySize sets the largest response body read
+func (rc *RestClient) WithMaxBodySize(size int64) *RestClient {
+	rc.maxBodySize = size
+	return rc
+}
+
+// WithRetry sets retry hook of failed requests, requests are not retried by default
+func (rc *RestClient) WithRetry(retry RetryFunc) *RestClient {
+	rc.retry = retry
+	return rc
+}
+
+// WithQuery adds query params to rawURL
+func WithQuery(rawURL string, query url.Values) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	for k, values := range query {
+		for _, v := range values {
+			q.Add(k, v)
+		}
 	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
 }
 
 // DoReq ...
 func (rc *RestClient) DoReq(url, method string, data, result interface{}) error {
-	var (
-		httpMethod = strings.ToUpper(method)
-		body       io.Reader
-	)
-	if httpMethod != http.MethodGet && data != nil {
-		dataBody, err := json.Marshal(data)
-		if err != nil {
+	return rc.Do(context.Background(), Request{Method: method, URL: url, Body: data}, result)
+}
+
+// Do sends req, retrying as told by retry hook, and decodes json response into result if not nil
+func (rc *RestClient) Do(ctx context.Context, req Request, result interface{}) error {
+	httpMethod := strings.ToUpper(req.Method)
+	switch httpMethod {
+	case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete:
+	default:
+		return errors.Errorf("invalid method %s", httpMethod)
+	}
+	reqURL := req.URL
+	if len(req.Query) != 0 {
+		var err error
+		if reqURL, err = WithQuery(req.URL, req.Query); err != nil {
+			return errors.Wrap(err, "invalid url")
+		}
+	}
+	var body []byte
+	if httpMethod != http.MethodGet && req.Body != nil {
+		var err error
+		if body, err = json.Marshal(req.Body); err != nil {
+			return err
+		}
+	}
+	for attempt := 1; ; attempt++ {
+		rspBody, err := rc.do(ctx, httpMethod, reqURL, req.Header, body)
+		if err == nil {
+			if result != nil {
+				return json.Unmarshal(rspBody, result)
+			}
+			return nil
+		}
+		if rc.retry == nil || ctx.Err() != nil {
 			return err
 		}
-		body = bytes.NewBuffer(dataBody)
+		wait, retry := rc.retry(attempt, err)
+		if !retry {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
 	}
-	req, err := http.NewRequest(httpMethod, url, body)
+}
+
+func (rc *RestClient) do(ctx context.Context, method, rawURL string, header http.Header, body []byte) ([]byte, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, reader)
 	if err != nil {
-		return errors.Wrap(err, "failed to create request")
+		return nil, errors.Wrap(err, "failed to create request")
 	}
-	switch httpMethod {
-	case http.MethodPost, http.MethodPut, http.MethodDelete:
-		req.Header.Add("Content-Type", "application/json")
-	case http.MethodGet:
-	default:
-		return errors.Errorf("invalid method %s", httpMethod)
+	for k, values := range rc.header {
+		req.Header[k] = values
+	}
+	for k, values := range header {
+		req.Header[k] = values
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
 	}
 	rsp, err := rc.c.Do(req)
 	if err != nil {
-		return errors.Wrap(err, "failed to do req")
+		// url.Error holds the full url, whose query may carry secrets such as api keys
+		var urlErr *url.Error
+		if stderrors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("failed to do req, %s %s: %w", method, req.URL.Host, err)
 	}
-	rspBody, err := ioutil.ReadAll(rsp.Body)
+	defer func() {
+		_ = rsp.Body.Close()
+	}()
+	rspBody, err := ioutil.ReadAll(io.LimitReader(rsp.Body, rc.maxBodySize+1))
 	if err != nil {
-		return errors.Wrap(err, "failed to read body")
-	}
-	if err := rsp.Body.Close(); err != nil {
-		return errors.Wrap(err, "failed to close body")
+		return nil, fmt.Errorf("failed to read body: %w", err)
 	}
-	if rsp.StatusCode != http.StatusOK {
-		var rspData interface{}
-		if err := json.Unmarshal(rspBody, &rspData); err != nil {
-			return errors.Wrap(err, "cannot unmarshal response data")
-		}
-		return errors.Errorf("receive unexpected code, actual code: %d, data: %+v", rsp.StatusCode, rspData)
+	if int64(len(rspBody)) > rc.maxBodySize {
+		return nil, ErrBodyTooLarge
 	}
-	if result != nil {
-		return json.Unmarshal(rspBody, result)
+	if rsp.StatusCode < http.StatusOK || rsp.StatusCode >= http.StatusMultipleChoices {
+		return nil, &HTTPError{StatusCode: rsp.StatusCode, Body: rspBody}
 	}
-	return nil
+	return rspBody, nil
 }
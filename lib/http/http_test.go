@@ -0,0 +1,78 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoErrors(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/unavailable":
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprint(w, "<html>maintenance</html>")
+		case "/large":
+			fmt.Fprint(w, strings.Repeat("a", 100))
+		}
+	}))
+	defer server.Close()
+
+	rc := NewRestClient(nil).WithRetry(RetryTransient(2, time.Millisecond))
+	err := rc.DoReq(server.URL+"/unavailable", http.MethodGet, nil, nil)
+	require.Equal(t, http.StatusServiceUnavailable, StatusCode(err))
+	require.Equal(t, "<html>maintenance</html>", string(err.(*HTTPError).Body))
+	require.Equal(t, 3, requests)
+
+	err = rc.WithMaxBodySize(10).DoReq(server.URL+"/large", http.MethodGet, nil, nil)
+	require.Equal(t, ErrBodyTooLarge, err)
+}
+
+func TestDoRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"query": %q, "auth": %q, "agent": %q}`, r.URL.RawQuery,
+			r.Header.Get("Authorization"), r.Header.Get("User-Agent"))
+	}))
+	defer server.Close()
+
+	var result map[string]string
+	err := NewRestClient(&http.Client{}).WithHeader("User-Agent", "contract-caller").Do(context.Background(), Request{
+		Method: http.MethodGet,
+		URL:    server.URL + "?module=contract",
+		Query:  url.Values{"address": []string{"0x01 02"}},
+		Header: http.Header{"Authorization": []string{"Bearer key"}},
+	}, &result)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"query": "address=0x01+02&module=contract",
+		"auth":  "Bearer key",
+		"agent": "contract-caller",
+	}, result)
+}
+
+func TestDoErrorHidesQuery(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	rc := NewRestClient(&http.Client{Timeout: 10 * time.Millisecond})
+	err := rc.DoReq(server.URL+"/api?module=contract&apikey=secret", http.MethodGet, nil, nil)
+	require.Error(t, err)
+	require.NotContains(t, err.Error(), "secret")
+	require.NotContains(t, err.Error(), "/api")
+	require.Contains(t, err.Error(), strings.TrimPrefix(server.URL, "http://"))
+	var netErr net.Error
+	require.True(t, errors.As(err, &netErr) && netErr.Timeout())
+}
@@ -0,0 +1,231 @@
+package render
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+
+	ethereum "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+const (
+	// FormatTable is aligned plain text columns
+	FormatTable = "table"
+	// FormatJSON is indented json
+	FormatJSON = "json"
+	// FormatCSV is comma separated values with a header row
+	FormatCSV = "csv"
+	// FormatMarkdown is a markdown table
+	FormatMarkdown = "markdown"
+	// FormatJSONLines is one json object per row, keyed by header
+	FormatJSONLines = "jsonl"
+)
+
+// Table is tabular data with stringified cells
+type Table struct {
+	Header []string
+	Rows   [][]string
+}
+
+// FormatValue returns readable string of a decoded abi value: big ints in decimal, addresses checksummed,
+// bytes in 0x-prefixed hex and slices as comma separated lists in brackets.
+func FormatValue(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case *big.Int:
+		return t.String()
+	case ethereum.Address:
+		return t.Hex()
+	case ethereum.Hash:
+		return t.Hex()
+	case []byte:
+		return hexutil.Encode(t)
+	case fmt.Stringer:
+		return t.String()
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, rv.Len())
+			reflect.Copy(reflect.ValueOf(b), rv)
+			return hexutil.Encode(b)
+		}
+		fallthrough
+	case reflect.Slice:
+		items := make([]string, rv.Len())
+		for i := range items {
+			items[i] = FormatValue(rv.Index(i).Interface())
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case reflect.Struct:
+		fields := make([]string, rv.NumField())
+		for i := range fields {
+			fields[i] = fmt.Sprintf("%s: %s", rv.Type().Field(i).Name, FormatValue(rv.Field(i).Interface()))
+		}
+		return "{" + strings.Join(fields, ", ") + "}"
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return ""
+		}
+		return FormatValue(rv.Elem().Interface())
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// FormatUnits formats raw integer amount with decimals, e.g. 1500000 with 6 decimals is 1.5
+func FormatUnits(raw *big.Int, decimals uint8) string {
+	if raw == nil {
+		return ""
+	}
+	if decimals == 0 {
+		return raw.String()
+	}
+	abs := new(big.Int).Abs(raw).String()
+	if len(abs) <= int(decimals) {
+		abs = strings.Repeat("0", int(decimals)-len(abs)+1) + abs
+	}
+	whole, fraction := abs[:len(abs)-int(decimals)], strings.TrimRight(abs[len(abs)-int(decimals):], "0")
+	result := whole
+	if fraction != "" {
+		result += "." + fraction
+	}
+	if raw.Sign() < 0 {
+		result = "-" + result
+	}
+	return result
+}
+
+// FormatParam formats a decoded abi value as a call param input, lists are comma separated without brackets
+func FormatParam(v interface{}) string {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8 {
+		items := make([]string, rv.Len())
+		for i := range items {
+			items[i] = FormatValue(rv.Index(i).Interface())
+		}
+		return strings.Join(items, ",")
+	}
+	return FormatValue(v)
+}
+
+// FormatResult formats each output of a call result, result is expected to be []interface{}
+func FormatResult(result interface{}) []string {
+	outputs, _ := result.([]interface{})
+	values := make([]string, len(outputs))
+	for i, o := range outputs {
+		values[i] = FormatValue(o)
+	}
+	return values
+}
+
+// Write writes table in given format, v is written instead of the table for json
+func Write(w io.Writer, format string, t Table, v interface{}) error {
+	switch format {
+	case FormatTable, "":
+		return WriteTable(w, t)
+	case FormatJSON:
+		return WriteJSON(w, v)
+	case FormatCSV:
+		return WriteCSV(w, t)
+	case FormatMarkdown:
+		return WriteMarkdown(w, t)
+	case FormatJSONLines:
+		return WriteJSONLines(w, t)
+	default:
+		return fmt.Errorf("unsupported format, format=%s", format)
+	}
+}
+
+// WriteTable writes table as aligned columns
+func WriteTable(w io.Writer, t Table) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	if len(t.Header) != 0 {
+		if _, err := fmt.Fprintln(tw, strings.Join(t.Header, "\t")); err != nil {
+			return err
+		}
+	}
+	for _, row := range t.Rows {
+		if _, err := fmt.Fprintln(tw, strings.Join(row, "\t")); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
+
+// WriteCSV writes table as csv
+func WriteCSV(w io.Writer, t Table) error {
+	cw := csv.NewWriter(w)
+	if len(t.Header) != 0 {
+		if err := cw.Write(t.Header); err != nil {
+			return err
+		}
+	}
+	if err := cw.WriteAll(t.Rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// WriteJSON writes v as indented json
+func WriteJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// WriteMarkdown writes table as a markdown table, pipes and new lines in cells are escaped
+func WriteMarkdown(w io.Writer, t Table) error {
+	escape := strings.NewReplacer("|", "\\|", "\n", "<br>")
+	writeRow := func(cells []string) error {
+		escaped := make([]string, len(cells))
+		for i, c := range cells {
+			escaped[i] = escape.Replace(c)
+		}
+		_, err := fmt.Fprintf(w, "| %s |\n", strings.Join(escaped, " | "))
+		return err
+	}
+	if err := writeRow(t.Header); err != nil {
+		return err
+	}
+	separator := make([]string, len(t.Header))
+	for i := range separator {
+		separator[i] = "---"
+	}
+	if err := writeRow(separator); err != nil {
+		return err
+	}
+	for _, row := range t.Rows {
+		if err := writeRow(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteJSONLines writes each row as a json object keyed by header, one per line
+func WriteJSONLines(w io.Writer, t Table) error {
+	enc := json.NewEncoder(w)
+	for _, row := range t.Rows {
+		obj := make(map[string]string, len(t.Header))
+		for i, h := range t.Header {
+			if i < len(row) {
+				obj[h] = row[i]
+			}
+		}
+		if err := enc.Encode(obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
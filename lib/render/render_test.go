@@ -0,0 +1,48 @@
+package render
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	ethereum "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatValue(t *testing.T) {
+	supply, _ := new(big.Int).SetString("1000000000000000000000000000", 10)
+	require.Equal(t, "1000000000000000000000000000", FormatValue(supply))
+	require.Equal(t, "0xBc5B5c036Eb41A1A85AF0B4Da13D56420e8A0a92",
+		FormatValue(ethereum.HexToAddress("0xbc5b5c036eb41a1a85af0b4da13d56420e8a0a92")))
+	require.Equal(t, "0x0102", FormatValue([2]byte{1, 2}))
+	require.Equal(t, "[1, 2]", FormatValue([]*big.Int{big.NewInt(1), big.NewInt(2)}))
+	require.Equal(t, "1,2", FormatParam([]*big.Int{big.NewInt(1), big.NewInt(2)}))
+	require.Equal(t, "true", FormatValue(true))
+}
+
+func TestFormatUnits(t *testing.T) {
+	require.Equal(t, "1.5", FormatUnits(big.NewInt(1500000), 6))
+	require.Equal(t, "0.000001", FormatUnits(big.NewInt(1), 6))
+	require.Equal(t, "-2", FormatUnits(big.NewInt(-2000), 3))
+	require.Equal(t, "42", FormatUnits(big.NewInt(42), 0))
+}
+
+func TestWrite(t *testing.T) {
+	table := Table{
+		Header: []string{"#", "value"},
+		Rows:   [][]string{{"0", "a|b"}, {"1", "c"}},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, FormatMarkdown, table, nil))
+	require.Equal(t, "| # | value |\n| --- | --- |\n| 0 | a\\|b |\n| 1 | c |\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, Write(&buf, FormatCSV, table, nil))
+	require.Equal(t, "#,value\n0,a|b\n1,c\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, Write(&buf, FormatJSONLines, table, nil))
+	require.Equal(t, "{\"#\":\"0\",\"value\":\"a|b\"}\n{\"#\":\"1\",\"value\":\"c\"}\n", buf.String())
+
+	require.Error(t, Write(&buf, "xml", table, nil))
+}
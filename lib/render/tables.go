@@ -0,0 +1,127 @@
+package render
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/KyberNetwork/contract-caller/common"
+)
+
+// CallTable is a table of outputs of a call result
+func CallTable(result interface{}) Table {
+	t := Table{Header: []string{"#", "value"}}
+	for i, v := range FormatResult(result) {
+		t.Rows = append(t.Rows, []string{strconv.Itoa(i), v})
+	}
+	return t
+}
+
+// MulticallTable is a table of multicall results, one row per call with outputs joined by " | "
+func MulticallTable(calls []common.CallRequest, results []common.CallResult) Table {
+	t := Table{Header: []string{"#", "contract", "method", "result", "err"}}
+	for i, r := range results {
+		t.Rows = append(t.Rows, []string{strconv.Itoa(i), calls[i].Contract, calls[i].Method,
+			strings.Join(FormatResult(r.Result), " | "), r.Err})
+	}
+	return t
+}
+
+// BatchTable is a table of batch results, one row per call with outputs joined by " | "
+func BatchTable(results []common.BatchResult) Table {
+	t := Table{Header: []string{"label", "network", "contract", "address", "method", "result", "err"}}
+	for _, r := range results {
+		t.Rows = append(t.Rows, []string{r.Label, r.Network, r.Contract, r.Address, r.Method,
+			strings.Join(FormatResult(r.Result), " | "), r.Err})
+	}
+	return t
+}
+
+// DiffTable is a table of state diffs, errors are shown in place of values
+func DiffTable(diffs []common.StateDiff) Table {
+	t := Table{Header: []string{"method", "from", "to", "changed"}}
+	for _, d := range diffs {
+		from, to := strings.Join(FormatResult(d.From), " | "), strings.Join(FormatResult(d.To), " | ")
+		if d.FromErr != "" {
+			from = "error: " + d.FromErr
+		}
+		if d.ToErr != "" {
+			to = "error: " + d.ToErr
+		}
+		t.Rows = append(t.Rows, []string{d.Method, from, to, strconv.FormatBool(d.Changed)})
+	}
+	return t
+}
+
+// HistoryTable is a table of recorded calls
+func HistoryTable(history []common.CallHistory) Table {
+	t := Table{Header: []string{"id", "time", "contract", "method", "block", "node", "latencyMs", "result", "err"}}
+	for _, h := range history {
+		t.Rows = append(t.Rows, []string{strconv.FormatInt(h.ID, 10), strconv.FormatInt(h.CreatedAt, 10), h.Contract,
+			h.Method, strconv.FormatUint(h.ResolvedBlock, 10), h.Node, strconv.FormatInt(h.LatencyMs, 10),
+			string(h.Result), h.Err})
+	}
+	return t
+}
+
+// PipelineTable is a table of pipeline step outputs, one row per named or indexed output
+func PipelineTable(result common.PipelineResult) Table {
+	t := Table{Header: []string{"block", "step", "contract", "method", "output", "value"}}
+	block := strconv.FormatUint(result.BlockNumber, 10)
+	for _, step := range result.Steps {
+		names := make([]string, 0, len(step.Outputs))
+		for name := range step.Outputs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			t.Rows = append(t.Rows, []string{block, step.Name, step.Contract, step.Method, name,
+				FormatValue(step.Outputs[name])})
+		}
+	}
+	return t
+}
+
+// BalanceTable is a table of formatted balances, one row per holder and a row of totals, followed by a row of
+// allowances per holder and spender
+func BalanceTable(matrix common.BalanceMatrix) Table {
+	t := Table{Header: []string{"holder"}}
+	for _, token := range matrix.Tokens {
+		name := token.Symbol
+		if name == "" {
+			name = token.Address
+		}
+		t.Header = append(t.Header, name)
+	}
+	for i, holder := range matrix.Holders {
+		row := []string{holder}
+		for _, cell := range matrix.Balances[i] {
+			value := cell.Formatted
+			if cell.Err != "" {
+				value = "error: " + cell.Err
+			}
+			row = append(row, value)
+		}
+		t.Rows = append(t.Rows, row)
+	}
+	totals := []string{"total"}
+	for _, token := range matrix.Tokens {
+		totals = append(totals, token.TotalFormatted)
+	}
+	t.Rows = append(t.Rows, totals)
+	for _, allowances := range matrix.Allowances {
+		row := []string{allowances.Holder + " allowance to " + allowances.Spender}
+		for j, cell := range allowances.Allowances {
+			value := cell.Formatted
+			switch {
+			case matrix.Tokens[j].Address == common.NativeToken:
+				value = "-"
+			case cell.Err != "":
+				value = "error: " + cell.Err
+			}
+			row = append(row, value)
+		}
+		t.Rows = append(t.Rows, row)
+	}
+	return t
+}
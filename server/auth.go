@@ -0,0 +1,266 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+
+	"github.com/KyberNetwork/contract-caller/common"
+	"github.com/KyberNetwork/contract-caller/core"
+)
+
+// apiKeyHeader is header of api keys, keys can also be sent as bearer token, or as apiKey query param to event
+// streams, which cannot set headers in browsers
+const apiKeyHeader = "X-API-Key"
+
+// apiKeyQuery is query param of api keys of event streams
+const apiKeyQuery = "apiKey"
+
+// apiKeyContextKey is key of the authenticated api key in gin context
+const apiKeyContextKey = "apiKey"
+
+// Config is configuration of server
+type Config struct {
+	// RequireAuth rejects requests without a valid api key, otherwise keys are optional but still checked
+	// and limited when given
+	RequireAuth bool
+	// CORSOrigins are origins allowed to call the api, all origins are allowed if empty
+	CORSOrigins []string
+	// AnonymousRole is role of requests without api key, viewer if empty
+	AnonymousRole string
+	// CustomNodeAllowlist are custom nodes (urls or hosts) any role can use, other custom nodes need admin role
+	CustomNodeAllowlist []string
+}
+
+// apiKeySecret returns api key given by the request, the query param is only read when query is set since urls
+// end up in logs and browser history
+func apiKeySecret(c *gin.Context, query bool) string {
+	if key := c.GetHeader(apiKeyHeader); key != "" {
+		return key
+	}
+	if auth := c.GetHeader("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+	}
+	if query {
+		return c.Query(apiKeyQuery)
+	}
+	return ""
+}
+
+// redactAPIKey replaces api key in query of a request path
+func redactAPIKey(path string) string {
+	i := strings.IndexByte(path, '?')
+	if i < 0 {
+		return path
+	}
+	query, err := url.ParseQuery(path[i+1:])
+	if err != nil {
+		return path[:i] + "?redacted"
+	}
+	if _, ok := query[apiKeyQuery]; !ok {
+		return path
+	}
+	query.Set(apiKeyQuery, "redacted")
+	return path[:i+1] + query.Encode()
+}
+
+// logFormatter is the default gin access log format with api keys redacted from paths
+func logFormatter(param gin.LogFormatterParams) string {
+	var statusColor, methodColor, resetColor string
+	if param.IsOutputColor() {
+		statusColor = param.StatusCodeColor()
+		methodColor = param.MethodColor()
+		resetColor = param.ResetColor()
+	}
+	if param.Latency > time.Minute {
+		param.Latency -= param.Latency % time.Second
+	}
+	return fmt.Sprintf("[GIN] %v |%s %3d %s| %13v | %15s |%s %-7s %s %#v\n%s",
+		param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+		statusColor, param.StatusCode, resetColor,
+		param.Latency,
+		param.ClientIP,
+		methodColor, param.Method, resetColor,
+		redactAPIKey(param.Path),
+		param.ErrorMessage,
+	)
+}
+
+// limiter returns rate limiter of an api key, nil if the key is not rate limited
+func (s *Server) limiter(k common.APIKey) *rate.Limiter {
+	if k.RateLimit == 0 {
+		return nil
+	}
+	s.limitersMu.Lock()
+	defer s.limitersMu.Unlock()
+	l, ok := s.limiters[k.ID]
+	if !ok || l.Limit() != rate.Limit(k.RateLimit) {
+		l = rate.NewLimiter(rate.Limit(k.RateLimit), int(math.Max(1, math.Ceil(k.RateLimit))))
+		s.limiters[k.ID] = l
+	}
+	return l
+}
+
+// authenticate checks api key of a request against rate limit and quota of the key
+func (s *Server) authenticate(c *gin.Context) {
+	s.authenticateKey(c, apiKeySecret(c, false))
+}
+
+// authenticateStream authenticates an event stream, which may also send its api key as query param
+func (s *Server) authenticateStream(c *gin.Context) {
+	s.authenticateKey(c, apiKeySecret(c, true))
+}
+
+func (s *Server) authenticateKey(c *gin.Context, secret string) {
+	if secret == "" {
+		if s.cfg.RequireAuth {
+			s.fail(c, &core.Error{
+				Code:    core.CodeUnauthorized,
+				Message: "api key is required, send it in " + apiKeyHeader + " header or as bearer token",
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+		return
+	}
+	k, err := s.core.Authenticate(secret)
+	if err != nil {
+		s.fail(c, err)
+		c.Abort()
+		return
+	}
+	if l := s.limiter(k); l != nil && !l.Allow() {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(1/k.RateLimit))))
+		s.fail(c, rateLimitExceeded(k))
+		c.Abort()
+		return
+	}
+	if err := s.core.UseAPIKey(k); err != nil {
+		s.fail(c, err)
+		c.Abort()
+		return
+	}
+	c.Set(apiKeyContextKey, k)
+	c.Next()
+}
+
+// rateLimitExceeded is error of requests beyond rate limit of an api key
+func rateLimitExceeded(k common.APIKey) *core.Error {
+	return &core.Error{
+		Code:    core.CodeRateLimited,
+		Message: "rate limit of api key is exceeded",
+		Details: map[string]interface{}{"rateLimit": k.RateLimit},
+	}
+}
+
+// chargeAPIKey counts one more request against rate limit and daily quota of the api key of the request, for
+// requests of a json-rpc batch after the first one, which authenticate counted
+func (s *Server) chargeAPIKey(c *gin.Context) error {
+	k, ok := apiKey(c)
+	if !ok {
+		return nil
+	}
+	if l := s.limiter(k); l != nil && !l.Allow() {
+		return rateLimitExceeded(k)
+	}
+	return s.core.UseAPIKey(k)
+}
+
+// apiKey returns api key the request is authenticated with
+func apiKey(c *gin.Context) (common.APIKey, bool) {
+	v, ok := c.Get(apiKeyContextKey)
+	if !ok {
+		return common.APIKey{}, false
+	}
+	k, ok := v.(common.APIKey)
+	return k, ok
+}
+
+// role returns role of the request, anonymous requests have the configured anonymous role
+func (s *Server) role(c *gin.Context) string {
+	if k, ok := apiKey(c); ok {
+		return k.Role
+	}
+	if s.cfg.AnonymousRole != "" {
+		return s.cfg.AnonymousRole
+	}
+	return common.RoleViewer
+}
+
+// actor identifies who sends the request in audit logs
+func (s *Server) actor(c *gin.Context) string {
+	if k, ok := apiKey(c); ok {
+		return fmt.Sprintf("%s (%s)", k.Name, k.Prefix)
+	}
+	return "anonymous@" + c.ClientIP()
+}
+
+// requireRole fails unless role of the request is allowed what required role is
+func (s *Server) requireRole(c *gin.Context, required, action string) error {
+	role := s.role(c)
+	if core.HasRole(role, required) {
+		return nil
+	}
+	return &core.Error{
+		Code:    core.CodeForbidden,
+		Message: fmt.Sprintf("%s needs %s role", action, required),
+		Details: map[string]interface{}{"role": role, "requiredRole": required},
+	}
+}
+
+// allowNode fails when the request uses a custom node outside the allowlist without admin role
+func (s *Server) allowNode(c *gin.Context, node string) error {
+	if node == "" || s.nodeAllowlisted(node) {
+		return nil
+	}
+	return s.requireRole(c, common.RoleAdmin, "custom node outside the allowlist")
+}
+
+// visibleNode returns node of a recorded call as shown to the request, only admins see full urls since paths and
+// queries of node urls often hold provider keys
+func (s *Server) visibleNode(c *gin.Context, node string) string {
+	return s.visibleURL(c, node, common.RoleAdmin)
+}
+
+// visibleWebhook returns webhook url of an alert as shown to the request, only editors see full urls since paths
+// of slack and discord webhooks are secrets
+func (s *Server) visibleWebhook(c *gin.Context, webhook string) string {
+	return s.visibleURL(c, webhook, common.RoleEditor)
+}
+
+// visibleURL returns rawURL to requests with required role, and only its scheme and host to others
+func (s *Server) visibleURL(c *gin.Context, rawURL, required string) string {
+	if rawURL == "" || core.HasRole(s.role(c), required) {
+		return rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return "redacted"
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// nodeAllowlisted tells whether node matches an allowlist entry, by url or by host
+func (s *Server) nodeAllowlisted(node string) bool {
+	u, err := url.Parse(node)
+	if err != nil {
+		return false
+	}
+	for _, allowed := range s.cfg.CustomNodeAllowlist {
+		if allowed == "" {
+			continue
+		}
+		if strings.TrimSuffix(allowed, "/") == strings.TrimSuffix(node, "/") ||
+			(u.Host != "" && strings.EqualFold(allowed, u.Host)) || strings.EqualFold(allowed, u.Hostname()) {
+			return true
+		}
+	}
+	return false
+}
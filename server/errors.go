@@ -0,0 +1,61 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/KyberNetwork/contract-caller/core"
+)
+
+// errorStatus maps error codes to http status codes
+var errorStatus = map[core.ErrorCode]int{
+	core.CodeValidation:   http.StatusBadRequest,
+	core.CodeNotFound:     http.StatusNotFound,
+	core.CodeRevert:       http.StatusUnprocessableEntity,
+	core.CodeUpstream:     http.StatusBadGateway,
+	core.CodeTimeout:      http.StatusGatewayTimeout,
+	core.CodeInternal:     http.StatusInternalServerError,
+	core.CodeUnauthorized: http.StatusUnauthorized,
+	core.CodeRateLimited:  http.StatusTooManyRequests,
+	core.CodeForbidden:    http.StatusForbidden,
+}
+
+// invalidInput is a validation error of a request which cannot be bound
+func invalidInput(err error) error {
+	return &core.Error{Code: core.CodeValidation, Message: err.Error(), Err: err}
+}
+
+// invalidArgument is a validation error of an argument of a request
+func invalidArgument(argument, format string, args ...interface{}) error {
+	return &core.Error{
+		Code:    core.CodeValidation,
+		Message: fmt.Sprintf(format, args...),
+		Details: map[string]interface{}{"argument": argument},
+	}
+}
+
+// fail writes err with the status of its code, body is {"err": message, "code": code, "details": {...}}
+func (s *Server) fail(c *gin.Context, err error) {
+	e := core.AsError(err)
+	status, ok := errorStatus[e.Code]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+	if status >= http.StatusInternalServerError {
+		s.sugar.Errorw("request failed", "path", c.FullPath(), "code", e.Code, "err", err)
+	}
+	details := e.Details
+	if details == nil {
+		details = map[string]interface{}{}
+	}
+	c.JSON(
+		status,
+		gin.H{
+			"err":     e.Message,
+			"code":    e.Code,
+			"details": details,
+		},
+	)
+}
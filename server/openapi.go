@@ -0,0 +1,264 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/KyberNetwork/contract-caller/lib/render"
+)
+
+// openAPIVersion is version of the api described by the openapi document
+const openAPIVersion = "0.0.1"
+
+var rawMessageType = reflect.TypeOf(json.RawMessage{})
+
+// schemaGenerator builds json schemas of go types, named structs are put in components
+type schemaGenerator struct {
+	components map[string]interface{}
+}
+
+func (g *schemaGenerator) schema(t reflect.Type) map[string]interface{} {
+	if t == rawMessageType {
+		return map[string]interface{}{}
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return g.schema(t.Elem())
+	case reflect.Interface:
+		return map[string]interface{}{}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case reflect.Int64, reflect.Uint, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Uint8:
+		return map[string]interface{}{"type": "integer", "minimum": 0, "maximum": 255}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		if _, ok := g.components[t.Name()]; !ok {
+			// placeholder stops recursion of self referencing types
+			g.components[t.Name()] = nil
+			g.components[t.Name()] = g.object(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+	}
+	return map[string]interface{}{}
+}
+
+// object is schema of struct fields with json tags, fields with binding:"required" are required
+func (g *schemaGenerator) object(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := tagName(f, "json")
+		if name == "" {
+			continue
+		}
+		properties[name] = g.schema(f.Type)
+		if isRequired(f) {
+			required = append(required, name)
+		}
+	}
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) != 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// tagName returns name of field in tag, empty for unexported or skipped fields
+func tagName(f reflect.StructField, tag string) string {
+	if f.PkgPath != "" {
+		return ""
+	}
+	name := strings.Split(f.Tag.Get(tag), ",")[0]
+	if name == "-" {
+		return ""
+	}
+	if name == "" && tag == "json" {
+		return f.Name
+	}
+	return name
+}
+
+func isRequired(f reflect.StructField) bool {
+	for _, rule := range strings.Split(f.Tag.Get("binding"), ",") {
+		if rule == "required" {
+			return true
+		}
+	}
+	return false
+}
+
+// queryParams returns query parameters of input, nil if input is not bound from query (has no form tags)
+func (g *schemaGenerator) queryParams(input reflect.Type) []interface{} {
+	var params []interface{}
+	for i := 0; i < input.NumField(); i++ {
+		f := input.Field(i)
+		name := tagName(f, "form")
+		if name == "" {
+			continue
+		}
+		params = append(params, map[string]interface{}{
+			"name":     name,
+			"in":       "query",
+			"required": isRequired(f),
+			"schema":   g.schema(f.Type),
+		})
+	}
+	return params
+}
+
+// pathParams returns parameters of :name segments of a gin path, and the path in openapi syntax
+func pathParams(path string) (string, []interface{}) {
+	var params []interface{}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, ":") {
+			continue
+		}
+		name := segment[1:]
+		segments[i] = "{" + name + "}"
+		params = append(params, map[string]interface{}{
+			"name":     name,
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]interface{}{"type": "string"},
+		})
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// errorSchema is schema of error responses
+func errorSchema() map[string]interface{} {
+	var codes []string
+	for code := range errorStatus {
+		codes = append(codes, string(code))
+	}
+	sort.Strings(codes)
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"err":     map[string]interface{}{"type": "string"},
+			"code":    map[string]interface{}{"type": "string", "enum": codes},
+			"details": map[string]interface{}{"type": "object"},
+		},
+		"required": []string{"err", "code", "details"},
+	}
+}
+
+// operation describes a route
+func (g *schemaGenerator) operation(rt route, params []interface{}) map[string]interface{} {
+	var body map[string]interface{}
+	if rt.input != nil {
+		input := reflect.TypeOf(rt.input)
+		if query := g.queryParams(input); len(query) != 0 {
+			params = append(params, query...)
+		} else {
+			body = g.schema(input)
+		}
+	}
+	envelope := map[string]interface{}{"data": g.schema(reflect.TypeOf(rt.output))}
+	for key, value := range rt.extra {
+		envelope[key] = g.schema(reflect.TypeOf(value))
+	}
+	content := map[string]interface{}{
+		"application/json": map[string]interface{}{
+			"schema": map[string]interface{}{"type": "object", "properties": envelope},
+		},
+	}
+	if rt.stream {
+		content = map[string]interface{}{
+			"text/event-stream": map[string]interface{}{"schema": g.schema(reflect.TypeOf(rt.output))},
+		}
+	}
+	if rt.export {
+		for format, contentType := range contentTypes {
+			content[strings.Split(contentType, ";")[0]] = map[string]interface{}{
+				"schema": map[string]interface{}{"type": "string", "description": "export format " + format},
+			}
+		}
+		params = append(params, map[string]interface{}{
+			"name": "format",
+			"in":   "query",
+			"schema": map[string]interface{}{
+				"type": "string",
+				"enum": []string{render.FormatJSON, render.FormatCSV, render.FormatJSONLines, render.FormatMarkdown},
+			},
+		})
+	}
+	op := map[string]interface{}{
+		"summary": rt.summary,
+		"responses": map[string]interface{}{
+			"200": map[string]interface{}{"description": "OK", "content": content},
+			"default": map[string]interface{}{
+				"description": "Error",
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{
+						"schema": map[string]interface{}{"$ref": "#/components/schemas/Error"},
+					},
+				},
+			},
+		},
+	}
+	if len(params) != 0 {
+		op["parameters"] = params
+	}
+	if body != nil {
+		op["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": body},
+			},
+		}
+	}
+	return op
+}
+
+// openAPIDocument describes routes as an openapi 3 document
+func openAPIDocument(routes []route) map[string]interface{} {
+	g := &schemaGenerator{components: map[string]interface{}{"Error": errorSchema()}}
+	paths := make(map[string]interface{})
+	for _, rt := range routes {
+		path, params := pathParams(rt.path)
+		item, ok := paths[path].(map[string]interface{})
+		if !ok {
+			item = make(map[string]interface{})
+			paths[path] = item
+		}
+		item[strings.ToLower(rt.method)] = g.operation(rt, params)
+	}
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "contract-caller",
+			"version": openAPIVersion,
+		},
+		"paths":      paths,
+		"components": map[string]interface{}{"schemas": g.components},
+	}
+}
+
+func (s *Server) openAPI(c *gin.Context) {
+	c.JSON(
+		http.StatusOK,
+		openAPIDocument(s.routes()),
+	)
+}
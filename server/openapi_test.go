@@ -0,0 +1,38 @@
+package server
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAPIDocument(t *testing.T) {
+	s := &Server{}
+	doc := openAPIDocument(s.routes())
+	raw, err := json.Marshal(doc)
+	require.NoError(t, err)
+
+	paths := doc["paths"].(map[string]interface{})
+	for _, rt := range s.routes() {
+		path, _ := pathParams(rt.path)
+		require.Contains(t, paths[path], strings.ToLower(rt.method), rt.path)
+	}
+
+	// every referenced schema is a component
+	schemas := doc["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+	for _, ref := range strings.Split(string(raw), `"$ref":"#/components/schemas/`)[1:] {
+		name := ref[:strings.Index(ref, `"`)]
+		require.NotNil(t, schemas[name], name)
+	}
+
+	call := paths["/contract/call"].(map[string]interface{})["post"].(map[string]interface{})
+	body := call["requestBody"].(map[string]interface{})["content"].(map[string]interface{})["application/json"]
+	require.Equal(t, map[string]interface{}{"$ref": "#/components/schemas/CallContractRequest"},
+		body.(map[string]interface{})["schema"])
+	require.Equal(t, []string{"contract", "method"}, schemas["CallContractRequest"].(map[string]interface{})["required"])
+
+	token := paths["/token/{address}/balance"].(map[string]interface{})["get"].(map[string]interface{})
+	require.Len(t, token["parameters"], 6)
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"bytes"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/KyberNetwork/contract-caller/lib/render"
+)
+
+// contentTypes maps export formats to response content types
+var contentTypes = map[string]string{
+	render.FormatCSV:       "text/csv; charset=utf-8",
+	render.FormatJSONLines: "application/x-ndjson; charset=utf-8",
+	render.FormatMarkdown:  "text/markdown; charset=utf-8",
+}
+
+// exportFormat returns export format requested by format query param, or by Accept header,
+// empty means the default json envelope
+func exportFormat(c *gin.Context) string {
+	if format := c.Query("format"); format != "" {
+		return format
+	}
+	for _, accept := range strings.Split(c.GetHeader("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accept))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case "text/csv":
+			return render.FormatCSV
+		case "application/x-ndjson", "application/jsonl", "application/jsonlines":
+			return render.FormatJSONLines
+		case "text/markdown":
+			return render.FormatMarkdown
+		}
+	}
+	return ""
+}
+
+// respond writes data in json envelope, or t in the export format requested by the client
+func (s *Server) respond(c *gin.Context, t render.Table, data interface{}) {
+	format := exportFormat(c)
+	contentType, ok := contentTypes[format]
+	if !ok {
+		if format != "" && format != render.FormatJSON {
+			s.fail(c, invalidArgument("format", "unsupported format, format=%s", format))
+			return
+		}
+		c.JSON(
+			http.StatusOK,
+			gin.H{
+				"data": data,
+			},
+		)
+		return
+	}
+	var buf bytes.Buffer
+	if err := render.Write(&buf, format, t, data); err != nil {
+		s.fail(c, err)
+		return
+	}
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
@@ -0,0 +1,284 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	ethereum "github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+
+	"github.com/KyberNetwork/contract-caller/common"
+	"github.com/KyberNetwork/contract-caller/core"
+)
+
+const (
+	rpcVersion = "2.0"
+	// maxRPCBatch is the largest number of requests in a batch
+	maxRPCBatch = 100
+)
+
+// json-rpc error codes, revert uses code 3 as nodes do for eth_call
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcInternalError  = -32603
+	rpcNotFound       = -32001
+	rpcUpstreamError  = -32002
+	rpcTimeout        = -32003
+	rpcForbidden      = -32004
+	rpcRateLimited    = -32005
+	rpcRevert         = 3
+)
+
+// rpcErrorCodes maps error codes of core to json-rpc error codes
+var rpcErrorCodes = map[core.ErrorCode]int{
+	core.CodeValidation:  rpcInvalidParams,
+	core.CodeNotFound:    rpcNotFound,
+	core.CodeUpstream:    rpcUpstreamError,
+	core.CodeTimeout:     rpcTimeout,
+	core.CodeRevert:      rpcRevert,
+	core.CodeInternal:    rpcInternalError,
+	core.CodeForbidden:   rpcForbidden,
+	core.CodeRateLimited: rpcRateLimited,
+}
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	// ID is empty for notifications, which are not answered
+	ID json.RawMessage `json:"id"`
+}
+
+type rpcError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// rpcMethod runs a json-rpc method with its raw params, c is the http request carrying the batch
+type rpcMethod func(c *gin.Context, params json.RawMessage) (interface{}, error)
+
+// rpcMethods are json-rpc methods, they take the request types of the matching routes as params
+func (s *Server) rpcMethods() map[string]rpcMethod {
+	return map[string]rpcMethod{
+		"cc_methods":   s.rpcContractMethods,
+		"cc_call":      s.rpcCall,
+		"cc_multicall": s.rpcMulticall,
+		"cc_decode":    s.rpcDecode,
+	}
+}
+
+// decodeParams reads params given by name, or as an array holding the params object, and validates them
+func decodeParams(raw json.RawMessage, v interface{}) error {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return &core.Error{Code: core.CodeValidation, Message: "missing params"}
+	}
+	if raw[0] == '[' {
+		var positional []json.RawMessage
+		if err := json.Unmarshal(raw, &positional); err != nil {
+			return invalidInput(err)
+		}
+		if len(positional) != 1 {
+			return &core.Error{Code: core.CodeValidation, Message: "params must be an object, or an array holding one object"}
+		}
+		raw = positional[0]
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return invalidInput(err)
+	}
+	if err := binding.Validator.ValidateStruct(v); err != nil {
+		return invalidInput(err)
+	}
+	return nil
+}
+
+func contractAddress(hex string) (ethereum.Address, error) {
+	if !ethereum.IsHexAddress(hex) {
+		return ethereum.Address{}, invalidArgument("contract", "contract is not a valid ethereum address")
+	}
+	return ethereum.HexToAddress(hex), nil
+}
+
+func (s *Server) rpcContractMethods(c *gin.Context, params json.RawMessage) (interface{}, error) {
+	var input common.MethodsRequest
+	if err := decodeParams(params, &input); err != nil {
+		return nil, err
+	}
+	contract, err := contractAddress(input.Contract)
+	if err != nil {
+		return nil, err
+	}
+	contractABI, err := interfaceABI(input.ABI, input.Interface)
+	if err != nil {
+		return nil, err
+	}
+	rememberBy, err := s.rememberBy(c, input)
+	if err != nil {
+		return nil, err
+	}
+	return s.core.ContractMethods(contract, contractABI, rememberBy, input.Network)
+}
+
+func (s *Server) rpcCall(c *gin.Context, params json.RawMessage) (interface{}, error) {
+	var input common.CallContractRequest
+	if err := decodeParams(params, &input); err != nil {
+		return nil, err
+	}
+	if err := s.allowNode(c, input.CustomNode); err != nil {
+		return nil, err
+	}
+	contract, err := contractAddress(input.Contract)
+	if err != nil {
+		return nil, err
+	}
+	contractABI, err := interfaceABI(input.ABI, input.Interface)
+	if err != nil {
+		return nil, err
+	}
+	return s.core.CallContractWithHistory(contract, contractABI, input.Method, input.BlockNumber, input.Params,
+		input.CustomNode)
+}
+
+func (s *Server) rpcMulticall(c *gin.Context, params json.RawMessage) (interface{}, error) {
+	var input common.MulticallRequest
+	if err := decodeParams(params, &input); err != nil {
+		return nil, err
+	}
+	if err := s.allowNode(c, input.CustomNode); err != nil {
+		return nil, err
+	}
+	return s.core.Multicall(input.Calls, input.BlockNumber, input.CustomNode)
+}
+
+func (s *Server) rpcDecode(c *gin.Context, params json.RawMessage) (interface{}, error) {
+	var input common.DecodeRequest
+	if err := decodeParams(params, &input); err != nil {
+		return nil, err
+	}
+	contractABI, err := interfaceABI(input.ABI, input.Interface)
+	if err != nil {
+		return nil, err
+	}
+	if contractABI != "" {
+		return core.DecodeData(contractABI, input.Method, input.Data)
+	}
+	contract, err := contractAddress(input.Contract)
+	if err != nil {
+		return nil, err
+	}
+	return s.core.Decode(contract, "", input.Network, input.Method, input.Data)
+}
+
+func rpcErrorResponse(id json.RawMessage, code int, message string) *rpcResponse {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	return &rpcResponse{JSONRPC: rpcVersion, ID: id, Error: &rpcError{Code: code, Message: message}}
+}
+
+// handleRPC runs a request, nil response means a notification. charge counts the request against the api key,
+// for requests of a batch after the first one
+func (s *Server) handleRPC(c *gin.Context, raw json.RawMessage, charge bool) *rpcResponse {
+	var req rpcRequest
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return rpcErrorResponse(nil, rpcInvalidRequest, fmt.Sprintf("invalid request, err: %s", err))
+	}
+	if req.JSONRPC != rpcVersion || req.Method == "" {
+		return rpcErrorResponse(req.ID, rpcInvalidRequest, "invalid request, jsonrpc must be 2.0 and method is required")
+	}
+	method, ok := s.rpcMethods()[req.Method]
+	if !ok {
+		if len(req.ID) == 0 {
+			return nil
+		}
+		return rpcErrorResponse(req.ID, rpcMethodNotFound, fmt.Sprintf("method not found, method=%s", req.Method))
+	}
+	var (
+		result interface{}
+		err    error
+	)
+	if charge {
+		err = s.chargeAPIKey(c)
+	}
+	if err == nil {
+		result, err = method(c, req.Params)
+	}
+	if len(req.ID) == 0 {
+		return nil
+	}
+	if err != nil {
+		e := core.AsError(err)
+		code, ok := rpcErrorCodes[e.Code]
+		if !ok {
+			code = rpcInternalError
+		}
+		if code == rpcInternalError {
+			s.sugar.Errorw("rpc request failed", "method", req.Method, "err", err)
+		}
+		details := e.Details
+		if details == nil {
+			details = map[string]interface{}{}
+		}
+		rsp := rpcErrorResponse(req.ID, code, e.Message)
+		rsp.Error.Data = gin.H{"code": e.Code, "details": details}
+		return rsp
+	}
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return rpcErrorResponse(req.ID, rpcInternalError, fmt.Sprintf("cannot encode result, err: %s", err))
+	}
+	return &rpcResponse{JSONRPC: rpcVersion, ID: req.ID, Result: encoded}
+}
+
+// rpc is the json-rpc 2.0 endpoint, a batch is an array of requests answered by an array of responses
+func (s *Server) rpc(c *gin.Context) {
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusOK, rpcErrorResponse(nil, rpcParseError, err.Error()))
+		return
+	}
+	body = bytes.TrimSpace(body)
+	if !json.Valid(body) {
+		c.JSON(http.StatusOK, rpcErrorResponse(nil, rpcParseError, "parse error"))
+		return
+	}
+	if len(body) == 0 || body[0] != '[' {
+		if rsp := s.handleRPC(c, body, false); rsp != nil {
+			c.JSON(http.StatusOK, rsp)
+			return
+		}
+		c.Status(http.StatusNoContent)
+		return
+	}
+	var batch []json.RawMessage
+	if err := json.Unmarshal(body, &batch); err != nil || len(batch) == 0 || len(batch) > maxRPCBatch {
+		c.JSON(http.StatusOK, rpcErrorResponse(nil, rpcInvalidRequest,
+			fmt.Sprintf("batch must have 1 to %d requests", maxRPCBatch)))
+		return
+	}
+	responses := make([]*rpcResponse, 0, len(batch))
+	for i, raw := range batch {
+		if rsp := s.handleRPC(c, raw, i > 0); rsp != nil {
+			responses = append(responses, rsp)
+		}
+	}
+	if len(responses) == 0 {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	c.JSON(http.StatusOK, responses)
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+
+	"github.com/KyberNetwork/contract-caller/common"
+)
+
+func postRPC(t *testing.T, body string) (int, string) {
+	gin.SetMode(gin.TestMode)
+	s := &Server{sugar: zap.S(), r: gin.New()}
+	s.r.POST("/rpc", s.rpc)
+	w := httptest.NewRecorder()
+	s.r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(body)))
+	return w.Code, w.Body.String()
+}
+
+func TestRPC(t *testing.T) {
+	calldata := "0x70a08231000000000000000000000000bc5b5c036eb41a1a85af0b4da13d56420e8a0a92"
+	code, body := postRPC(t, `{"jsonrpc":"2.0","id":1,"method":"cc_decode",`+
+		`"params":[{"interface":"erc20","data":"`+calldata+`"}]}`)
+	require.Equal(t, http.StatusOK, code)
+	var rsp struct {
+		ID     int `json:"id"`
+		Result struct {
+			Method string `json:"method"`
+		} `json:"result"`
+		Error *rpcError `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(body), &rsp))
+	require.Nil(t, rsp.Error)
+	require.Equal(t, 1, rsp.ID)
+	require.Equal(t, "balanceOf", rsp.Result.Method)
+
+	_, body = postRPC(t, `[`+
+		`{"jsonrpc":"2.0","id":"a","method":"cc_unknown"},`+
+		`{"jsonrpc":"2.0","id":"b","method":"cc_call","params":{"contract":"0x01","method":"symbol"}},`+
+		`{"jsonrpc":"2.0","method":"cc_decode","params":{}},`+
+		`{"jsonrpc":"1.0","id":"c","method":"cc_call"}]`)
+	var batch []struct {
+		ID    string   `json:"id"`
+		Error rpcError `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(body), &batch))
+	require.Len(t, batch, 3)
+	require.Equal(t, rpcMethodNotFound, batch[0].Error.Code)
+	require.Equal(t, rpcInvalidParams, batch[1].Error.Code)
+	require.Equal(t, map[string]interface{}{"code": "validation", "details": map[string]interface{}{"argument": "contract"}},
+		batch[1].Error.Data)
+	require.Equal(t, rpcInvalidRequest, batch[2].Error.Code)
+
+	_, body = postRPC(t, `{"jsonrpc":`)
+	require.Contains(t, body, `"code":-32700`)
+	_, body = postRPC(t, `[]`)
+	require.Contains(t, body, `"code":-32600`)
+	code, _ = postRPC(t, `{"jsonrpc":"2.0","method":"cc_decode","params":{}}`)
+	require.Equal(t, http.StatusNoContent, code)
+}
+
+func TestRPCBatchRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := &Server{sugar: zap.S(), r: gin.New(), limiters: make(map[int64]*rate.Limiter)}
+	k := common.APIKey{ID: 1, Name: "indexer", Role: common.RoleViewer, RateLimit: 1}
+	// the request itself used the only token, as authenticate would
+	require.True(t, s.limiter(k).Allow())
+	s.r.POST("/rpc", func(c *gin.Context) { c.Set(apiKeyContextKey, k) }, s.rpc)
+
+	calldata := "0x70a08231000000000000000000000000bc5b5c036eb41a1a85af0b4da13d56420e8a0a92"
+	decode := `{"jsonrpc":"2.0","id":%d,"method":"cc_decode","params":[{"interface":"erc20","data":"` + calldata + `"}]}`
+	w := httptest.NewRecorder()
+	s.r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(
+		"["+fmt.Sprintf(decode, 1)+","+fmt.Sprintf(decode, 2)+","+fmt.Sprintf(decode, 3)+"]")))
+	var batch []struct {
+		ID     int             `json:"id"`
+		Result json.RawMessage `json:"result"`
+		Error  *rpcError       `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &batch))
+	require.Len(t, batch, 3)
+	require.Nil(t, batch[0].Error)
+	for _, rsp := range batch[1:] {
+		require.NotNil(t, rsp.Error)
+		require.Equal(t, rpcRateLimited, rsp.Error.Code)
+	}
+}
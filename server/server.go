@@ -1,16 +1,24 @@
 package server
 
 import (
+	"encoding/json"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
+	"golang.org/x/time/rate"
 
 	ethereum "github.com/ethereum/go-ethereum/common"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 
+	"github.com/KyberNetwork/contract-caller/common"
 	"github.com/KyberNetwork/contract-caller/core"
+	"github.com/KyberNetwork/contract-caller/lib/render"
 )
 
 // Server ...
@@ -19,60 +27,268 @@ type Server struct {
 	host  string
 	r     *gin.Engine
 	core  *core.Core
+	cfg   Config
+
+	limitersMu sync.Mutex
+	// limiters are rate limiters by api key id
+	limiters map[int64]*rate.Limiter
 }
 
 // NewServer ...
-func NewServer(host string, core *core.Core) *Server {
+func NewServer(host string, core *core.Core, cfg Config) *Server {
 
-	r := gin.Default()
+	r := gin.New()
+	r.Use(gin.LoggerWithFormatter(logFormatter), gin.Recovery())
 	corsConfig := cors.DefaultConfig()
-	corsConfig.AllowAllOrigins = true
+	if len(cfg.CORSOrigins) != 0 {
+		corsConfig.AllowOrigins = cfg.CORSOrigins
+	} else {
+		corsConfig.AllowAllOrigins = true
+	}
+	corsConfig.AddAllowHeaders("Authorization", apiKeyHeader)
 	corsConfig.MaxAge = 5 * time.Minute
 	r.Use(cors.New(corsConfig))
 
 	return &Server{
-		sugar: zap.S(),
-		host:  host,
-		r:     r,
-		core:  core,
+		sugar:    zap.S(),
+		host:     host,
+		r:        r,
+		core:     core,
+		cfg:      cfg,
+		limiters: make(map[int64]*rate.Limiter),
 	}
 }
 
-type inputMethods struct {
-	Contract    string `json:"contract" binding:"required"`
-	ABI         string `json:"abi"`
-	RememberABI bool   `json:"rememberABI"`
-	Network     string `json:"network"`
+func (s *Server) methods(c *gin.Context) {
+	var input common.MethodsRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		s.fail(c, invalidInput(err))
+		return
+	}
+	if !ethereum.IsHexAddress(input.Contract) {
+		s.fail(c, invalidArgument("contract", "contract is not a valid ethereum address"))
+		return
+	}
+	contractABI, err := interfaceABI(input.ABI, input.Interface)
+	if err != nil {
+		s.fail(c, err)
+		return
+	}
+	rememberBy, err := s.rememberBy(c, input)
+	if err != nil {
+		s.fail(c, err)
+		return
+	}
+	result, err := s.core.ContractMethods(ethereum.HexToAddress(input.Contract), contractABI, rememberBy, input.Network)
+	if err != nil {
+		s.fail(c, err)
+		return
+	}
+	// contract is metadata of verified contracts, kept apart from data so clients reading methods still work
+	response := gin.H{
+		"data": result,
+	}
+	if source, err := s.core.ContractSource(ethereum.HexToAddress(input.Contract), input.Network); err == nil {
+		response["contract"] = source
+	}
+	c.JSON(
+		http.StatusOK,
+		response,
+	)
 }
 
-func (s *Server) methods(c *gin.Context) {
-	var input inputMethods
+// rememberBy returns actor storing abi of a methods request, empty if the abi is not stored. Storing abi
+// needs editor role
+func (s *Server) rememberBy(c *gin.Context, input common.MethodsRequest) (string, error) {
+	if !input.RememberABI {
+		return "", nil
+	}
+	if err := s.requireRole(c, common.RoleEditor, "storing abi"); err != nil {
+		return "", err
+	}
+	return s.actor(c), nil
+}
+
+// interfaceABI returns abi of the standard interface when no abi is given, for contracts without verified abi
+func interfaceABI(contractABI, iface string) (string, error) {
+	if contractABI != "" || iface == "" {
+		return contractABI, nil
+	}
+	return core.StandardABI(iface)
+}
+
+func (s *Server) call(c *gin.Context) {
+	var input common.CallContractRequest
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(
-			http.StatusOK,
-			gin.H{
-				"err": err.Error(),
-			},
-		)
+		s.fail(c, invalidInput(err))
+		return
+	}
+	if err := s.allowNode(c, input.CustomNode); err != nil {
+		s.fail(c, err)
 		return
 	}
 	if !ethereum.IsHexAddress(input.Contract) {
-		c.JSON(
-			http.StatusOK,
-			gin.H{
-				"err": "contract is not a valid ethereum address",
-			},
-		)
+		s.fail(c, invalidArgument("contract", "contract is not a valid ethereum address"))
+		return
+	}
+	contractABI, err := interfaceABI(input.ABI, input.Interface)
+	if err != nil {
+		s.fail(c, err)
+		return
+	}
+	result, err := s.core.CallContractWithHistory(ethereum.HexToAddress(input.Contract), contractABI,
+		input.Method, input.BlockNumber, input.Params, input.CustomNode)
+	if err != nil {
+		s.fail(c, err)
+		return
+	}
+	s.respond(c, render.CallTable(result), result)
+}
+
+func (s *Server) diff(c *gin.Context) {
+	var input common.DiffRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		s.fail(c, invalidInput(err))
+		return
+	}
+	if err := s.allowNode(c, input.CustomNode); err != nil {
+		s.fail(c, err)
+		return
+	}
+	if !ethereum.IsHexAddress(input.Contract) {
+		s.fail(c, invalidArgument("contract", "contract is not a valid ethereum address"))
+		return
+	}
+	result, err := s.core.DiffContractState(ethereum.HexToAddress(input.Contract), input.ABI,
+		input.Network, input.FromBlock, input.ToBlock, input.CustomNode)
+	if err != nil {
+		s.fail(c, err)
+		return
+	}
+	s.respond(c, render.DiffTable(result), result)
+}
+
+// watch streams call result changes as server-sent events
+func (s *Server) watch(c *gin.Context) {
+	var input common.WatchQuery
+	if err := c.ShouldBindQuery(&input); err != nil {
+		s.fail(c, invalidInput(err))
+		return
+	}
+	if err := s.allowNode(c, input.CustomNode); err != nil {
+		s.fail(c, err)
+		return
+	}
+	if !ethereum.IsHexAddress(input.Contract) {
+		s.fail(c, invalidArgument("contract", "contract is not a valid ethereum address"))
+		return
+	}
+	var params map[string]interface{}
+	if input.Params != "" {
+		if err := json.Unmarshal([]byte(input.Params), &params); err != nil {
+			s.fail(c, invalidArgument("params", "cannot parse params, err: %s", err.Error()))
+			return
+		}
+	}
+	updates, err := s.core.WatchContract(c.Request.Context(), ethereum.HexToAddress(input.Contract), input.ABI,
+		input.Network, input.Method, params, input.CustomNode)
+	if err != nil {
+		s.fail(c, err)
+		return
+	}
+	c.Stream(func(w io.Writer) bool {
+		u, ok := <-updates
+		if !ok {
+			return false
+		}
+		c.SSEvent("update", u)
+		return true
+	})
+}
+
+func (s *Server) createAlert(c *gin.Context) {
+	if err := s.requireRole(c, common.RoleEditor, "creating alerts"); err != nil {
+		s.fail(c, err)
+		return
+	}
+	var input common.Alert
+	if err := c.ShouldBindJSON(&input); err != nil {
+		s.fail(c, invalidInput(err))
+		return
+	}
+	id, err := s.core.CreateAlert(input)
+	if err != nil {
+		s.fail(c, err)
+		return
+	}
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"data": id,
+		},
+	)
+}
+
+func (s *Server) alerts(c *gin.Context) {
+	result, err := s.core.Alerts()
+	if err != nil {
+		s.fail(c, err)
+		return
+	}
+	for i := range result {
+		result[i].WebhookURL = s.visibleWebhook(c, result[i].WebhookURL)
+	}
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"data": result,
+		},
+	)
+}
+
+func (s *Server) deleteAlert(c *gin.Context) {
+	if err := s.requireRole(c, common.RoleEditor, "deleting alerts"); err != nil {
+		s.fail(c, err)
 		return
 	}
-	result, err := s.core.ContractMethods(ethereum.HexToAddress(input.Contract), input.ABI, input.RememberABI, input.Network)
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(
-			http.StatusOK,
-			gin.H{
-				"err": err.Error(),
-			},
-		)
+		s.fail(c, invalidArgument("id", "alert id is not a valid number"))
+		return
+	}
+	if err := s.core.DeleteAlert(id); err != nil {
+		s.fail(c, err)
+		return
+	}
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"data": id,
+		},
+	)
+}
+
+func (s *Server) saveQuery(c *gin.Context) {
+	if err := s.requireRole(c, common.RoleEditor, "saving queries"); err != nil {
+		s.fail(c, err)
+		return
+	}
+	var input common.SavedQuery
+	if err := c.ShouldBindJSON(&input); err != nil {
+		s.fail(c, invalidInput(err))
+		return
+	}
+	var (
+		result common.SavedQuery
+		err    error
+	)
+	if id := c.Param("id"); id != "" {
+		result, err = s.core.UpdateQuery(id, input)
+	} else {
+		result, err = s.core.SaveQuery(input)
+	}
+	if err != nil {
+		s.fail(c, err)
 		return
 	}
 	c.JSON(
@@ -83,45 +299,371 @@ func (s *Server) methods(c *gin.Context) {
 	)
 }
 
-// inputCall ...
-type inputCall struct {
-	Contract    string                 `json:"contract" binding:"required"`
-	ABI         string                 `json:"abi"`
-	Method      string                 `json:"method" binding:"required"`
-	BlockNumber string                 `json:"blockNumber"`
-	Params      map[string]interface{} `json:"params"`
-	CustomNode  string                 `json:"customNode"`
+func (s *Server) searchQueries(c *gin.Context) {
+	var input common.QuerySearch
+	if err := c.ShouldBindQuery(&input); err != nil {
+		s.fail(c, invalidInput(err))
+		return
+	}
+	result, err := s.core.SearchQueries(input.Tag, input.Text)
+	if err != nil {
+		s.fail(c, err)
+		return
+	}
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"data": result,
+		},
+	)
 }
 
-func (s *Server) call(c *gin.Context) {
-	var input inputCall
+func (s *Server) query(c *gin.Context) {
+	result, err := s.core.Query(c.Param("id"))
+	if err != nil {
+		s.fail(c, err)
+		return
+	}
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"data": result,
+		},
+	)
+}
+
+func (s *Server) deleteQuery(c *gin.Context) {
+	if err := s.requireRole(c, common.RoleEditor, "deleting queries"); err != nil {
+		s.fail(c, err)
+		return
+	}
+	if err := s.core.DeleteQuery(c.Param("id")); err != nil {
+		s.fail(c, err)
+		return
+	}
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"data": c.Param("id"),
+		},
+	)
+}
+
+func (s *Server) runQuery(c *gin.Context) {
+	var input common.RunQueryQuery
+	if err := c.ShouldBindQuery(&input); err != nil {
+		s.fail(c, invalidInput(err))
+		return
+	}
+	if err := s.allowNode(c, input.CustomNode); err != nil {
+		s.fail(c, err)
+		return
+	}
+	result, err := s.core.RunQuery(c.Param("id"), input.CustomNode)
+	if err != nil {
+		s.fail(c, err)
+		return
+	}
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"data": result,
+		},
+	)
+}
+
+func (s *Server) history(c *gin.Context) {
+	var input common.HistoryQuery
+	if err := c.ShouldBindQuery(&input); err != nil {
+		s.fail(c, invalidInput(err))
+		return
+	}
+	result, err := s.core.CallHistory(common.HistoryFilter{
+		Contract: input.Contract,
+		Method:   input.Method,
+		From:     input.From,
+		To:       input.To,
+		Limit:    input.Limit,
+	})
+	if err != nil {
+		s.fail(c, err)
+		return
+	}
+	for i := range result {
+		result[i].Node = s.visibleNode(c, result[i].Node)
+	}
+	s.respond(c, render.HistoryTable(result), result)
+}
+
+// replay re-executes a recorded call, at=latest replays at latest block instead of the original one
+func (s *Server) replay(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		s.fail(c, invalidArgument("id", "history id is not a valid number"))
+		return
+	}
+	var input common.ReplayQuery
+	if err := c.ShouldBindQuery(&input); err != nil {
+		s.fail(c, invalidInput(err))
+		return
+	}
+	// the recorded node is checked as if the caller gave it, a call made by an admin cannot be replayed by others
+	h, err := s.core.RecordedCall(id)
+	if err != nil {
+		s.fail(c, err)
+		return
+	}
+	if err := s.allowNode(c, h.Node); err != nil {
+		s.fail(c, err)
+		return
+	}
+	result, err := s.core.ReplayCall(id, input.At == "latest")
+	if err != nil {
+		s.fail(c, err)
+		return
+	}
+	result.Original.Node = s.visibleNode(c, result.Original.Node)
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"data": result,
+		},
+	)
+}
+
+// batch runs a batch file given as yaml or json body
+func (s *Server) batch(c *gin.Context) {
+	body, err := c.GetRawData()
+	if err != nil {
+		s.fail(c, invalidInput(err))
+		return
+	}
+	b, err := core.ParseBatch(body)
+	if err != nil {
+		s.fail(c, err)
+		return
+	}
+	for _, n := range b.Networks {
+		if err := s.allowNode(c, n.Node); err != nil {
+			s.fail(c, err)
+			return
+		}
+	}
+	result, err := s.core.RunBatch(b)
+	if err != nil {
+		s.fail(c, err)
+		return
+	}
+	s.respond(c, render.BatchTable(result), result)
+}
+
+func (s *Server) pipeline(c *gin.Context) {
+	var input common.PipelineRequest
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(
-			http.StatusOK,
-			gin.H{
-				"err": err.Error(),
-			},
-		)
+		s.fail(c, invalidInput(err))
+		return
+	}
+	if err := s.allowNode(c, input.CustomNode); err != nil {
+		s.fail(c, err)
+		return
+	}
+	result, err := s.core.RunPipeline(input.Steps, input.BlockNumber, input.Network, input.CustomNode)
+	if err != nil {
+		s.fail(c, err)
+		return
+	}
+	s.respond(c, render.PipelineTable(result), result)
+}
+
+func (s *Server) multicall(c *gin.Context) {
+	var input common.MulticallRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		s.fail(c, invalidInput(err))
+		return
+	}
+	if err := s.allowNode(c, input.CustomNode); err != nil {
+		s.fail(c, err)
+		return
+	}
+	result, err := s.core.Multicall(input.Calls, input.BlockNumber, input.CustomNode)
+	if err != nil {
+		s.fail(c, err)
+		return
+	}
+	s.respond(c, render.MulticallTable(input.Calls, result), result)
+}
+
+// token handles /token/:address, /token/:address/balance and /token/:address/allowance
+func (s *Server) token(c *gin.Context) {
+	var input common.TokenQuery
+	if err := c.ShouldBindQuery(&input); err != nil {
+		s.fail(c, invalidInput(err))
+		return
+	}
+	if err := s.allowNode(c, input.CustomNode); err != nil {
+		s.fail(c, err)
+		return
+	}
+	action := strings.TrimPrefix(c.FullPath(), "/token/:address")
+	addresses := map[string]string{"token": c.Param("address")}
+	switch action {
+	case "/balance":
+		addresses["owner"] = input.Owner
+	case "/allowance":
+		addresses["owner"], addresses["spender"] = input.Owner, input.Spender
+	}
+	for name, address := range addresses {
+		if !ethereum.IsHexAddress(address) {
+			s.fail(c, invalidArgument(name, "%s is not a valid ethereum address", name))
+			return
+		}
+	}
+	var (
+		token  = ethereum.HexToAddress(c.Param("address"))
+		result interface{}
+		err    error
+	)
+	switch action {
+	case "":
+		result, err = s.core.TokenInfo(token, input.BlockNumber, input.CustomNode)
+	case "/balance":
+		result, err = s.core.TokenBalance(token, ethereum.HexToAddress(input.Owner), input.ID,
+			input.BlockNumber, input.CustomNode)
+	case "/allowance":
+		result, err = s.core.TokenAllowance(token, ethereum.HexToAddress(input.Owner),
+			ethereum.HexToAddress(input.Spender), input.BlockNumber, input.CustomNode)
+	}
+	if err != nil {
+		s.fail(c, err)
+		return
+	}
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"data": result,
+		},
+	)
+}
+
+func (s *Server) balances(c *gin.Context) {
+	var input common.BalancesRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		s.fail(c, invalidInput(err))
+		return
+	}
+	if err := s.allowNode(c, input.CustomNode); err != nil {
+		s.fail(c, err)
+		return
+	}
+	result, err := s.core.BalanceMatrix(input.Holders, input.Tokens, input.Spenders, input.BlockNumber, input.CustomNode)
+	if err != nil {
+		s.fail(c, err)
+		return
+	}
+	s.respond(c, render.BalanceTable(result), result)
+}
+
+func (s *Server) storage(c *gin.Context) {
+	var input common.StorageRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		s.fail(c, invalidInput(err))
+		return
+	}
+	if err := s.allowNode(c, input.CustomNode); err != nil {
+		s.fail(c, err)
 		return
 	}
 	if !ethereum.IsHexAddress(input.Contract) {
-		c.JSON(
-			http.StatusOK,
-			gin.H{
-				"err": "contract is not a valid ethereum address",
-			},
-		)
+		s.fail(c, invalidArgument("contract", "contract is not a valid ethereum address"))
 		return
 	}
-	result, err := s.core.CallContract(ethereum.HexToAddress(input.Contract), input.ABI,
-		input.Method, input.BlockNumber, input.Params, input.CustomNode)
+	// layout can be given as json or as a json encoded string
+	var layout string
+	if err := json.Unmarshal(input.Layout, &layout); err != nil && len(input.Layout) != 0 {
+		layout = string(input.Layout)
+	}
+	var rememberBy string
+	if input.RememberLayout {
+		if err := s.requireRole(c, common.RoleEditor, "storing storage layouts"); err != nil {
+			s.fail(c, err)
+			return
+		}
+		rememberBy = s.actor(c)
+	}
+	result, err := s.core.ReadStorage(ethereum.HexToAddress(input.Contract), input.Slots, input.Variables,
+		layout, rememberBy, input.BlockNumber, input.CustomNode)
+	if err != nil {
+		s.fail(c, err)
+		return
+	}
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"data": result,
+		},
+	)
+}
+
+func (s *Server) interfaces(c *gin.Context) {
+	names := core.StandardInterfaces()
+	result := make(map[string]json.RawMessage, len(names))
+	for _, name := range names {
+		rawABI, err := core.StandardABI(name)
+		if err != nil {
+			s.fail(c, err)
+			return
+		}
+		result[name] = json.RawMessage(rawABI)
+	}
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"data": result,
+		},
+	)
+}
+
+func (s *Server) detectInterfaces(c *gin.Context) {
+	var input common.NodeQuery
+	if err := c.ShouldBindQuery(&input); err != nil {
+		s.fail(c, invalidInput(err))
+		return
+	}
+	if err := s.allowNode(c, input.CustomNode); err != nil {
+		s.fail(c, err)
+		return
+	}
+	if !ethereum.IsHexAddress(c.Param("address")) {
+		s.fail(c, invalidArgument("contract", "contract is not a valid ethereum address"))
+		return
+	}
+	result, err := s.core.DetectInterfaces(ethereum.HexToAddress(c.Param("address")), input.BlockNumber, input.CustomNode)
+	if err != nil {
+		s.fail(c, err)
+		return
+	}
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"data": result,
+		},
+	)
+}
+
+// abiAudit returns latest abi writes, only admins can read them
+func (s *Server) abiAudit(c *gin.Context) {
+	if err := s.requireRole(c, common.RoleAdmin, "reading abi audit log"); err != nil {
+		s.fail(c, err)
+		return
+	}
+	var input common.ABIAuditQuery
+	if err := c.ShouldBindQuery(&input); err != nil {
+		s.fail(c, invalidInput(err))
+		return
+	}
+	result, err := s.core.ABIAuditLog(input.Contract, input.Limit)
 	if err != nil {
-		c.JSON(
-			http.StatusOK,
-			gin.H{
-				"err": err.Error(),
-			},
-		)
+		s.fail(c, err)
 		return
 	}
 	c.JSON(
@@ -132,16 +674,125 @@ func (s *Server) call(c *gin.Context) {
 	)
 }
 
+func (s *Server) layoutAudit(c *gin.Context) {
+	if err := s.requireRole(c, common.RoleAdmin, "reading storage layout audit log"); err != nil {
+		s.fail(c, err)
+		return
+	}
+	var input common.LayoutAuditQuery
+	if err := c.ShouldBindQuery(&input); err != nil {
+		s.fail(c, invalidInput(err))
+		return
+	}
+	result, err := s.core.LayoutAuditLog(input.Contract, input.Limit)
+	if err != nil {
+		s.fail(c, err)
+		return
+	}
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"data": result,
+		},
+	)
+}
+
+// abiVersions returns abi versions of a contract, latest first
+func (s *Server) abiVersions(c *gin.Context) {
+	contract, err := contractAddress(c.Param("address"))
+	if err != nil {
+		s.fail(c, err)
+		return
+	}
+	result, err := s.core.ABIVersions(contract)
+	if err != nil {
+		s.fail(c, err)
+		return
+	}
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"data": result,
+		},
+	)
+}
+
+// rollbackABI stores an earlier abi version of a contract again, it needs editor role as other abi writes
+func (s *Server) rollbackABI(c *gin.Context) {
+	if err := s.requireRole(c, common.RoleEditor, "storing abi"); err != nil {
+		s.fail(c, err)
+		return
+	}
+	contract, err := contractAddress(c.Param("address"))
+	if err != nil {
+		s.fail(c, err)
+		return
+	}
+	var input common.ABIRollbackRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		s.fail(c, invalidInput(err))
+		return
+	}
+	result, err := s.core.RollbackABI(contract, input.Version, s.actor(c))
+	if err != nil {
+		s.fail(c, err)
+		return
+	}
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"data": result,
+		},
+	)
+}
+
+// diffABI diffs two abi versions of a contract
+func (s *Server) diffABI(c *gin.Context) {
+	contract, err := contractAddress(c.Param("address"))
+	if err != nil {
+		s.fail(c, err)
+		return
+	}
+	var input common.ABIDiffQuery
+	if err := c.ShouldBindQuery(&input); err != nil {
+		s.fail(c, invalidInput(err))
+		return
+	}
+	result, err := s.core.DiffABIVersions(contract, input.From, input.To)
+	if err != nil {
+		s.fail(c, err)
+		return
+	}
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"data": result,
+		},
+	)
+}
+
+func (s *Server) networks(c *gin.Context) {
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"data": common.Networks(),
+		},
+	)
+}
+
 func (s *Server) networkInfo(c *gin.Context) {
-	node := c.Query("node")
-	networkInfo, err := s.core.NetworkInfo(node)
+	var input common.NetworkInfoQuery
+	if err := c.ShouldBindQuery(&input); err != nil {
+		s.fail(c, invalidInput(err))
+		return
+	}
+	if err := s.allowNode(c, input.Node); err != nil {
+		s.fail(c, err)
+		return
+	}
+	networkInfo, err := s.core.NetworkInfo(input.Node)
 	if err != nil {
-		c.JSON(
-			http.StatusOK,
-			gin.H{
-				"err": err.Error(),
-			},
-		)
+		s.fail(c, err)
 		return
 	}
 	c.JSON(
@@ -152,11 +803,110 @@ func (s *Server) networkInfo(c *gin.Context) {
 	)
 }
 
+// route is an api endpoint, input is its body type (json tags) or query type (form tags) and output is type of
+// data in the response envelope
+type route struct {
+	method  string
+	path    string
+	summary string
+	handler gin.HandlerFunc
+	input   interface{}
+	output  interface{}
+	// export tells the endpoint also answers in export formats
+	export bool
+	// stream tells the endpoint answers output as server-sent events
+	stream bool
+	// extra is other keys of the response envelope
+	extra map[string]interface{}
+}
+
+// routes are all api endpoints, used to register handlers and to describe them in the openapi document
+func (s *Server) routes() []route {
+	return []route{
+		{method: http.MethodPost, path: "/contract/methods", summary: "List methods of a contract",
+			handler: s.methods, input: common.MethodsRequest{}, output: []common.Method{},
+			extra: map[string]interface{}{"contract": common.ContractSource{}}},
+		{method: http.MethodPost, path: "/contract/call", summary: "Call a view method of a contract",
+			handler: s.call, input: common.CallContractRequest{}, output: new(interface{}), export: true},
+		{method: http.MethodPost, path: "/contract/multicall", summary: "Call methods of several contracts at one block",
+			handler: s.multicall, input: common.MulticallRequest{}, output: []common.CallResult{}, export: true},
+		{method: http.MethodPost, path: "/contract/diff", summary: "Diff view methods of a contract between two blocks",
+			handler: s.diff, input: common.DiffRequest{}, output: []common.StateDiff{}, export: true},
+		{method: http.MethodGet, path: "/contract/watch", summary: "Stream changes of a call result",
+			handler: s.watch, input: common.WatchQuery{}, output: common.WatchUpdate{}, stream: true},
+		{method: http.MethodPost, path: "/contract/pipeline", summary: "Run calls referencing outputs of earlier calls",
+			handler: s.pipeline, input: common.PipelineRequest{}, output: common.PipelineResult{}, export: true},
+		{method: http.MethodGet, path: "/contract/network-info", summary: "Get network of a node",
+			handler: s.networkInfo, input: common.NetworkInfoQuery{}, output: ""},
+		{method: http.MethodGet, path: "/contract/networks", summary: "List known networks",
+			handler: s.networks, output: []common.Network{}},
+		{method: http.MethodPost, path: "/contract/storage", summary: "Read storage slots and state variables",
+			handler: s.storage, input: common.StorageRequest{}, output: common.StorageResult{}},
+		{method: http.MethodGet, path: "/contract/storage/audit", summary: "List storage layout writes, admin only",
+			handler: s.layoutAudit, input: common.LayoutAuditQuery{}, output: []common.LayoutAudit{}},
+		{method: http.MethodGet, path: "/contract/interfaces", summary: "List abi of standard interfaces",
+			handler: s.interfaces, output: map[string]json.RawMessage{}},
+		{method: http.MethodGet, path: "/contract/interfaces/:address", summary: "Detect standard interfaces of a contract",
+			handler: s.detectInterfaces, input: common.NodeQuery{}, output: []common.InterfaceMatch{}},
+		{method: http.MethodPost, path: "/batch", summary: "Run a batch file given as yaml or json",
+			handler: s.batch, input: common.Batch{}, output: []common.BatchResult{}, export: true},
+
+		{method: http.MethodGet, path: "/token/:address", summary: "Get token metadata",
+			handler: s.token, input: common.TokenQuery{}, output: common.TokenInfo{}},
+		{method: http.MethodGet, path: "/token/:address/balance", summary: "Get token balance of an owner",
+			handler: s.token, input: common.TokenQuery{}, output: common.TokenAmount{}},
+		{method: http.MethodGet, path: "/token/:address/allowance", summary: "Get token allowance of a spender",
+			handler: s.token, input: common.TokenQuery{}, output: common.TokenAmount{}},
+		{method: http.MethodPost, path: "/token/balances", summary: "Get balances of holders for tokens",
+			handler: s.balances, input: common.BalancesRequest{}, output: common.BalanceMatrix{}, export: true},
+
+		{method: http.MethodPost, path: "/alert", summary: "Create an alert",
+			handler: s.createAlert, input: common.Alert{}, output: int64(0)},
+		{method: http.MethodGet, path: "/alert", summary: "List alerts",
+			handler: s.alerts, output: []common.Alert{}},
+		{method: http.MethodDelete, path: "/alert/:id", summary: "Delete an alert",
+			handler: s.deleteAlert, output: int64(0)},
+
+		{method: http.MethodPost, path: "/query", summary: "Save a query",
+			handler: s.saveQuery, input: common.SavedQuery{}, output: common.SavedQuery{}},
+		{method: http.MethodGet, path: "/query", summary: "Search saved queries",
+			handler: s.searchQueries, input: common.QuerySearch{}, output: []common.SavedQuery{}},
+		{method: http.MethodGet, path: "/query/:id", summary: "Get a saved query",
+			handler: s.query, output: common.SavedQuery{}},
+		{method: http.MethodPut, path: "/query/:id", summary: "Update a saved query",
+			handler: s.saveQuery, input: common.SavedQuery{}, output: common.SavedQuery{}},
+		{method: http.MethodDelete, path: "/query/:id", summary: "Delete a saved query",
+			handler: s.deleteQuery, output: ""},
+		{method: http.MethodPost, path: "/query/:id/run", summary: "Run a saved query",
+			handler: s.runQuery, input: common.RunQueryQuery{}, output: new(interface{})},
+
+		{method: http.MethodGet, path: "/history", summary: "Search call history",
+			handler: s.history, input: common.HistoryQuery{}, output: []common.CallHistory{}, export: true},
+		{method: http.MethodPost, path: "/history/:id/replay", summary: "Replay a recorded call",
+			handler: s.replay, input: common.ReplayQuery{}, output: common.Replay{}},
+
+		{method: http.MethodGet, path: "/abi/audit", summary: "List abi writes, admin only",
+			handler: s.abiAudit, input: common.ABIAuditQuery{}, output: []common.ABIAudit{}},
+		{method: http.MethodGet, path: "/abi/versions/:address", summary: "List abi versions of a contract",
+			handler: s.abiVersions, output: []common.ABIVersion{}},
+		{method: http.MethodPost, path: "/abi/rollback/:address", summary: "Store an earlier abi version again",
+			handler: s.rollbackABI, input: common.ABIRollbackRequest{}, output: common.ABIVersion{}},
+		{method: http.MethodGet, path: "/abi/diff/:address", summary: "Diff functions, events and errors of two abi versions",
+			handler: s.diffABI, input: common.ABIDiffQuery{}, output: common.ABIDiff{}},
+	}
+}
+
 func (s *Server) register() {
-	g := s.r.Group("contract")
-	g.POST("/methods", s.methods)
-	g.POST("/call", s.call)
-	g.GET("/network-info", s.networkInfo)
+	for _, rt := range s.routes() {
+		authenticate := s.authenticate
+		if rt.stream {
+			authenticate = s.authenticateStream
+		}
+		s.r.Handle(rt.method, rt.path, authenticate, rt.handler)
+	}
+	s.r.GET("/openapi.json", s.openAPI)
+	// json-rpc methods take the same types as the routes, they are not described in the openapi document
+	s.r.POST("/rpc", s.authenticate, s.rpc)
 }
 
 // Run ...
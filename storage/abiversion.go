@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/KyberNetwork/contract-caller/common"
+)
+
+type abiVersionRecord struct {
+	ID        int64  `db:"id"`
+	Contract  string `db:"contract"`
+	ABI       string `db:"abi"`
+	Source    string `db:"source"`
+	Author    string `db:"author"`
+	CreatedAt int64  `db:"created_at"`
+}
+
+func (r abiVersionRecord) toABIVersion() common.ABIVersion {
+	return common.ABIVersion(r)
+}
+
+// addABIVersion inserts a version of the abi of contract unless it is the latest version already
+func addABIVersion(tx *sqlx.Tx, contract, abi, source, author string) error {
+	var (
+		latestQuery = `SELECT abi FROM "abi_versions" WHERE contract=$1 ORDER BY id DESC LIMIT 1;`
+		query       = `INSERT INTO "abi_versions" (contract, abi, source, author, created_at)
+			VALUES ($1, $2, $3, $4, $5);`
+		latest string
+	)
+	if err := tx.Get(&latest, latestQuery, contract); err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if latest == abi {
+		return nil
+	}
+	_, err := tx.Exec(query, contract, abi, source, author, time.Now().Unix())
+	return err
+}
+
+// GetABIVersions returns abi versions of contract, latest first
+func (s *Storage) GetABIVersions(contract string) ([]common.ABIVersion, error) {
+	var (
+		query   = `SELECT * FROM "abi_versions" WHERE contract=$1 ORDER BY id DESC;`
+		records []abiVersionRecord
+	)
+	if err := s.db.Select(&records, query, contract); err != nil {
+		return nil, err
+	}
+	versions := make([]common.ABIVersion, 0, len(records))
+	for _, r := range records {
+		versions = append(versions, r.toABIVersion())
+	}
+	return versions, nil
+}
+
+// GetABIVersion returns an abi version of contract by id, nil if not found
+func (s *Storage) GetABIVersion(contract string, id int64) (*common.ABIVersion, error) {
+	var (
+		query  = `SELECT * FROM "abi_versions" WHERE contract=$1 AND id=$2;`
+		record abiVersionRecord
+	)
+	if err := s.db.Get(&record, query, contract, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	v := record.toABIVersion()
+	return &v, nil
+}
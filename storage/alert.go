@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"encoding/json"
+
+	"github.com/KyberNetwork/contract-caller/common"
+)
+
+type alertRecord struct {
+	ID            int64  `db:"id"`
+	Name          string `db:"name"`
+	Contract      string `db:"contract"`
+	Network       string `db:"network"`
+	Method        string `db:"method"`
+	Params        string `db:"params"`
+	Condition     string `db:"condition"`
+	Threshold     string `db:"threshold"`
+	WebhookURL    string `db:"webhook_url"`
+	WebhookFormat string `db:"webhook_format"`
+	LastValue     string `db:"last_value"`
+	Triggered     bool   `db:"triggered"`
+}
+
+func (r alertRecord) toAlert() (common.Alert, error) {
+	var params map[string]interface{}
+	if err := json.Unmarshal([]byte(r.Params), &params); err != nil {
+		return common.Alert{}, err
+	}
+	return common.Alert{
+		ID:            r.ID,
+		Name:          r.Name,
+		Contract:      r.Contract,
+		Network:       r.Network,
+		Method:        r.Method,
+		Params:        params,
+		Condition:     r.Condition,
+		Threshold:     r.Threshold,
+		WebhookURL:    r.WebhookURL,
+		WebhookFormat: r.WebhookFormat,
+		LastValue:     r.LastValue,
+		Triggered:     r.Triggered,
+	}, nil
+}
+
+// CreateAlert stores a new alert and returns its id
+func (s *Storage) CreateAlert(a common.Alert) (int64, error) {
+	var (
+		query = `INSERT INTO "alerts" (name, contract, network, method, params, condition, threshold,
+			webhook_url, webhook_format) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);`
+	)
+	params, err := json.Marshal(a.Params)
+	if err != nil {
+		return 0, err
+	}
+	queryX, err := s.db.Preparex(query)
+	if err != nil {
+		return 0, err
+	}
+	res, err := queryX.Exec(a.Name, a.Contract, a.Network, a.Method, string(params), a.Condition, a.Threshold,
+		a.WebhookURL, a.WebhookFormat)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// GetAlerts returns all alerts
+func (s *Storage) GetAlerts() ([]common.Alert, error) {
+	var (
+		query   = `SELECT * FROM "alerts" ORDER BY id;`
+		records []alertRecord
+	)
+	if err := s.db.Select(&records, query); err != nil {
+		return nil, err
+	}
+	alerts := make([]common.Alert, 0, len(records))
+	for _, r := range records {
+		a, err := r.toAlert()
+		if err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, a)
+	}
+	return alerts, nil
+}
+
+// UpdateAlertState stores last evaluated value and triggered state of an alert
+func (s *Storage) UpdateAlertState(id int64, lastValue string, triggered bool) error {
+	var (
+		query = `UPDATE "alerts" SET last_value=$1, triggered=$2 WHERE id=$3;`
+	)
+	if _, err := s.db.Exec(query, lastValue, triggered, id); err != nil {
+		return err
+	}
+	return nil
+}
+
+// DeleteAlert ...
+func (s *Storage) DeleteAlert(id int64) error {
+	var (
+		query = `DELETE FROM "alerts" WHERE id=$1;`
+	)
+	if _, err := s.db.Exec(query, id); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/KyberNetwork/contract-caller/common"
+)
+
+func TestAlerts(t *testing.T) {
+	s, err := NewStorage(filepath.Join(t.TempDir(), "db_test.db"))
+	require.NoError(t, err)
+	alert := common.Alert{
+		Name:          "paused",
+		Contract:      "0xbc5b5c036eb41a1a85af0b4da13d56420e8a0a92",
+		Method:        "paused",
+		Params:        map[string]interface{}{},
+		Condition:     common.AlertConditionEquals,
+		Threshold:     "true",
+		WebhookURL:    "http://localhost/hook",
+		WebhookFormat: common.WebhookFormatSlack,
+	}
+	id, err := s.CreateAlert(alert)
+	require.NoError(t, err)
+
+	require.NoError(t, s.UpdateAlertState(id, "true", true))
+	alerts, err := s.GetAlerts()
+	require.NoError(t, err)
+	var found *common.Alert
+	for i := range alerts {
+		if alerts[i].ID == id {
+			found = &alerts[i]
+		}
+	}
+	require.NotNil(t, found)
+	require.Equal(t, alert.Name, found.Name)
+	require.Equal(t, "true", found.LastValue)
+	require.True(t, found.Triggered)
+
+	require.NoError(t, s.DeleteAlert(id))
+	alerts, err = s.GetAlerts()
+	require.NoError(t, err)
+	for _, a := range alerts {
+		require.NotEqual(t, id, a.ID)
+	}
+}
@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"database/sql"
+
+	"github.com/KyberNetwork/contract-caller/common"
+)
+
+type apiKeyRecord struct {
+	ID         int64   `db:"id"`
+	Name       string  `db:"name"`
+	Prefix     string  `db:"prefix"`
+	Role       string  `db:"role"`
+	KeyHash    string  `db:"key_hash"`
+	RateLimit  float64 `db:"rate_limit"`
+	DailyQuota int64   `db:"daily_quota"`
+	Revoked    bool    `db:"revoked"`
+	CreatedAt  int64   `db:"created_at"`
+}
+
+func (r apiKeyRecord) toAPIKey() common.APIKey {
+	return common.APIKey{
+		ID:         r.ID,
+		Name:       r.Name,
+		Prefix:     r.Prefix,
+		Role:       r.Role,
+		RateLimit:  r.RateLimit,
+		DailyQuota: r.DailyQuota,
+		Revoked:    r.Revoked,
+		CreatedAt:  r.CreatedAt,
+	}
+}
+
+// StoreAPIKey inserts an api key by hash of its secret and returns its id
+func (s *Storage) StoreAPIKey(k common.APIKey, keyHash string) (int64, error) {
+	var (
+		query = `INSERT INTO "api_keys" (name, prefix, role, key_hash, rate_limit, daily_quota, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7);`
+	)
+	queryX, err := s.db.Preparex(query)
+	if err != nil {
+		return 0, err
+	}
+	res, err := queryX.Exec(k.Name, k.Prefix, k.Role, keyHash, k.RateLimit, k.DailyQuota, k.CreatedAt)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// GetAPIKeyByHash returns api key by hash of its secret, nil if not found
+func (s *Storage) GetAPIKeyByHash(keyHash string) (*common.APIKey, error) {
+	var (
+		query  = `SELECT * FROM "api_keys" WHERE key_hash=$1;`
+		record apiKeyRecord
+	)
+	if err := s.db.Get(&record, query, keyHash); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	k := record.toAPIKey()
+	return &k, nil
+}
+
+// GetAPIKeys returns all api keys
+func (s *Storage) GetAPIKeys() ([]common.APIKey, error) {
+	var (
+		query   = `SELECT * FROM "api_keys" ORDER BY id;`
+		records []apiKeyRecord
+	)
+	if err := s.db.Select(&records, query); err != nil {
+		return nil, err
+	}
+	keys := make([]common.APIKey, 0, len(records))
+	for _, r := range records {
+		keys = append(keys, r.toAPIKey())
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey marks an api key revoked, it returns false if there is no such key
+func (s *Storage) RevokeAPIKey(id int64) (bool, error) {
+	var (
+		query = `UPDATE "api_keys" SET revoked=TRUE WHERE id=$1;`
+	)
+	res, err := s.db.Exec(query, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n != 0, err
+}
+
+// IncreaseAPIKeyUsage counts a request of an api key on a day and returns the count of that day
+func (s *Storage) IncreaseAPIKeyUsage(id int64, day string) (int64, error) {
+	var (
+		update = `INSERT INTO "api_key_usage" (key_id, day, count) VALUES ($1, $2, 1)
+			ON CONFLICT (key_id, day) DO UPDATE SET count = count + 1;`
+		query = `SELECT count FROM "api_key_usage" WHERE key_id=$1 AND day=$2;`
+		count int64
+	)
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	if _, err := tx.Exec(update, id, day); err != nil {
+		return 0, err
+	}
+	if err := tx.Get(&count, query, id, day); err != nil {
+		return 0, err
+	}
+	return count, tx.Commit()
+}
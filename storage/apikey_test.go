@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KyberNetwork/contract-caller/common"
+)
+
+func TestAPIKeys(t *testing.T) {
+	s, err := NewStorage(filepath.Join(t.TempDir(), "db_test.db"))
+	require.NoError(t, err)
+	k := common.APIKey{Name: "indexer", Prefix: "cc_test", Role: common.RoleEditor, RateLimit: 2, DailyQuota: 100, CreatedAt: 1}
+	// hashes are unique, the test db is kept between runs
+	keyHash := fmt.Sprintf("hash-%d", time.Now().UnixNano())
+	id, err := s.StoreAPIKey(k, keyHash)
+	require.NoError(t, err)
+	k.ID = id
+
+	stored, err := s.GetAPIKeyByHash(keyHash)
+	require.NoError(t, err)
+	require.Equal(t, &k, stored)
+	stored, err = s.GetAPIKeyByHash("unknown")
+	require.NoError(t, err)
+	require.Nil(t, stored)
+
+	count, err := s.IncreaseAPIKeyUsage(id, "2021-01-01")
+	require.NoError(t, err)
+	require.Equal(t, int64(1), count)
+	count, err = s.IncreaseAPIKeyUsage(id, "2021-01-01")
+	require.NoError(t, err)
+	require.Equal(t, int64(2), count)
+
+	ok, err := s.RevokeAPIKey(id)
+	require.NoError(t, err)
+	require.True(t, ok)
+	keys, err := s.GetAPIKeys()
+	require.NoError(t, err)
+	require.Contains(t, keys, common.APIKey{ID: id, Name: "indexer", Prefix: "cc_test", Role: common.RoleEditor, RateLimit: 2,
+		DailyQuota: 100, Revoked: true, CreatedAt: 1})
+}
+
+func TestAPIKeysRoleMigration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "migration_test.db")
+	// api_keys as created before roles
+	db, err := sqlx.Open("sqlite3", path)
+	require.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE "api_keys" (
+		id          INTEGER PRIMARY KEY AUTOINCREMENT,
+		name        TEXT NOT NULL,
+		prefix      TEXT NOT NULL,
+		key_hash    TEXT NOT NULL UNIQUE,
+		rate_limit  REAL NOT NULL,
+		daily_quota INTEGER NOT NULL,
+		revoked     BOOLEAN NOT NULL DEFAULT FALSE,
+		created_at  INTEGER NOT NULL
+	);
+	INSERT INTO "api_keys" (name, prefix, key_hash, rate_limit, daily_quota, created_at)
+	VALUES ('old', 'cc_old', 'hash-old', 0, 0, 1);`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	s, err := NewStorage(path)
+	require.NoError(t, err)
+	old, err := s.GetAPIKeyByHash("hash-old")
+	require.NoError(t, err)
+	require.Equal(t, common.RoleViewer, old.Role)
+	_, err = s.StoreAPIKey(common.APIKey{Name: "new", Prefix: "cc_new", Role: common.RoleAdmin, CreatedAt: 1}, "hash-new")
+	require.NoError(t, err)
+
+	// migrations are applied once
+	_, err = NewStorage(path)
+	require.NoError(t, err)
+}
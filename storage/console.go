@@ -0,0 +1,26 @@
+package storage
+
+import "time"
+
+// AddConsoleHistory appends a line to console history
+func (s *Storage) AddConsoleHistory(line string) error {
+	var (
+		query = `INSERT INTO "console_history" (line, created_at) VALUES ($1, $2);`
+	)
+	if _, err := s.db.Exec(query, line, time.Now().Unix()); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetConsoleHistory returns the last limit lines of console history, oldest first
+func (s *Storage) GetConsoleHistory(limit int) ([]string, error) {
+	var (
+		query = `SELECT line FROM (SELECT id, line FROM "console_history" ORDER BY id DESC LIMIT $1) ORDER BY id;`
+		lines []string
+	)
+	if err := s.db.Select(&lines, query, limit); err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
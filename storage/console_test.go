@@ -0,0 +1,19 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsoleHistory(t *testing.T) {
+	s, err := NewStorage(filepath.Join(t.TempDir(), "db_test.db"))
+	require.NoError(t, err)
+	require.NoError(t, s.AddConsoleHistory("load 0xbc5b5c036eb41a1a85af0b4da13d56420e8a0a92"))
+	require.NoError(t, s.AddConsoleHistory("totalSupply"))
+
+	lines, err := s.GetConsoleHistory(2)
+	require.NoError(t, err)
+	require.Equal(t, []string{"load 0xbc5b5c036eb41a1a85af0b4da13d56420e8a0a92", "totalSupply"}, lines)
+}
@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/KyberNetwork/contract-caller/common"
+)
+
+type historyRecord struct {
+	ID            int64  `db:"id"`
+	Contract      string `db:"contract"`
+	ABI           string `db:"abi"`
+	Method        string `db:"method"`
+	Params        string `db:"params"`
+	BlockNumber   string `db:"block_number"`
+	ResolvedBlock uint64 `db:"resolved_block"`
+	Node          string `db:"node"`
+	Result        string `db:"result"`
+	Err           string `db:"err"`
+	LatencyMs     int64  `db:"latency_ms"`
+	CreatedAt     int64  `db:"created_at"`
+}
+
+func (r historyRecord) toHistory() (common.CallHistory, error) {
+	var params map[string]interface{}
+	if err := json.Unmarshal([]byte(r.Params), &params); err != nil {
+		return common.CallHistory{}, err
+	}
+	h := common.CallHistory{
+		ID:            r.ID,
+		Contract:      r.Contract,
+		ABI:           r.ABI,
+		Method:        r.Method,
+		Params:        params,
+		BlockNumber:   r.BlockNumber,
+		ResolvedBlock: r.ResolvedBlock,
+		Node:          r.Node,
+		Err:           r.Err,
+		LatencyMs:     r.LatencyMs,
+		CreatedAt:     r.CreatedAt,
+	}
+	if r.Result != "" {
+		h.Result = json.RawMessage(r.Result)
+	}
+	return h, nil
+}
+
+// StoreCallHistory records a call and returns its id
+func (s *Storage) StoreCallHistory(h common.CallHistory) (int64, error) {
+	var (
+		query = `INSERT INTO "history" (contract, abi, method, params, block_number, resolved_block, node,
+			result, err, latency_ms, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11);`
+	)
+	params, err := json.Marshal(h.Params)
+	if err != nil {
+		return 0, err
+	}
+	queryX, err := s.db.Preparex(query)
+	if err != nil {
+		return 0, err
+	}
+	res, err := queryX.Exec(h.Contract, h.ABI, h.Method, string(params), h.BlockNumber, h.ResolvedBlock, h.Node,
+		string(h.Result), h.Err, h.LatencyMs, h.CreatedAt)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// GetCallHistory returns recorded call by id, nil if not found
+func (s *Storage) GetCallHistory(id int64) (*common.CallHistory, error) {
+	var (
+		query  = `SELECT * FROM "history" WHERE id=$1;`
+		record historyRecord
+	)
+	if err := s.db.Get(&record, query, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	h, err := record.toHistory()
+	if err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+// SearchCallHistory returns recorded calls matching filter, newest first
+func (s *Storage) SearchCallHistory(f common.HistoryFilter) ([]common.CallHistory, error) {
+	var (
+		query = `SELECT * FROM "history"
+			WHERE ($1 = '' OR contract = $1)
+			AND ($2 = '' OR method = $2)
+			AND ($3 = 0 OR created_at >= $3)
+			AND ($4 = 0 OR created_at <= $4)
+			ORDER BY id DESC LIMIT $5;`
+		records []historyRecord
+	)
+	if err := s.db.Select(&records, query, f.Contract, f.Method, f.From, f.To, f.Limit); err != nil {
+		return nil, err
+	}
+	history := make([]common.CallHistory, 0, len(records))
+	for _, r := range records {
+		h, err := r.toHistory()
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, h)
+	}
+	return history, nil
+}
@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/KyberNetwork/contract-caller/common"
+)
+
+func TestCallHistory(t *testing.T) {
+	s, err := NewStorage(filepath.Join(t.TempDir(), "db_test.db"))
+	require.NoError(t, err)
+	h := common.CallHistory{
+		Contract:      "0xBC5b5C036eB41A1A85aF0B4dA13D56420e8a0A92",
+		ABI:           "abi",
+		Method:        "totalSupply",
+		Params:        map[string]interface{}{},
+		ResolvedBlock: 11000000,
+		Result:        json.RawMessage(`[1000]`),
+		LatencyMs:     12,
+		CreatedAt:     100,
+	}
+	id, err := s.StoreCallHistory(h)
+	require.NoError(t, err)
+	h.ID = id
+
+	stored, err := s.GetCallHistory(id)
+	require.NoError(t, err)
+	require.Equal(t, h, *stored)
+
+	found, err := s.SearchCallHistory(common.HistoryFilter{Contract: h.Contract, Method: h.Method, From: 100, Limit: 10})
+	require.NoError(t, err)
+	require.Contains(t, found, h)
+	found, err = s.SearchCallHistory(common.HistoryFilter{Contract: h.Contract, From: 101, Limit: 10})
+	require.NoError(t, err)
+	require.NotContains(t, found, h)
+}
@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum/common"
+
+	"github.com/KyberNetwork/contract-caller/common"
+)
+
+// GetStorageLayout returns compiler storage layout of given contract in db, empty if there is none
+func (s *Storage) GetStorageLayout(contract ethereum.Address) (string, error) {
+	var (
+		query  = `SELECT layout FROM "storage_layouts" WHERE contract=$1;`
+		layout string
+	)
+	queryX, err := s.db.Preparex(query)
+	if err != nil {
+		return "", err
+	}
+	if err := queryX.Get(&layout, contract.Hex()); err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", err
+	}
+	return layout, nil
+}
+
+// StoreStorageLayout stores compiler storage layout of given contract, and records the write with actor and
+// previous layout in the audit log
+func (s *Storage) StoreStorageLayout(contract ethereum.Address, layout, actor string) error {
+	var (
+		previousQuery = `SELECT layout FROM "storage_layouts" WHERE contract=$1;`
+		query         = `REPLACE INTO "storage_layouts" (contract, layout) VALUES ($1, $2);`
+		auditQuery    = `INSERT INTO "layout_audit_log" (contract, actor, previous_layout, layout, created_at)
+			VALUES ($1, $2, $3, $4, $5);`
+		previous string
+	)
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	if err := tx.Get(&previous, previousQuery, contract.Hex()); err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if _, err := tx.Exec(query, contract.Hex(), layout); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(auditQuery, contract.Hex(), actor, previous, layout, time.Now().Unix()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetLayoutAuditLog returns latest storage layout writes, of a contract if not empty
+func (s *Storage) GetLayoutAuditLog(contract string, limit int) ([]common.LayoutAudit, error) {
+	var (
+		query = `SELECT id, contract, actor, previous_layout, layout, created_at FROM "layout_audit_log"
+			WHERE ($1 = '' OR contract = $1) ORDER BY id DESC LIMIT $2;`
+		records []struct {
+			ID             int64  `db:"id"`
+			Contract       string `db:"contract"`
+			Actor          string `db:"actor"`
+			PreviousLayout string `db:"previous_layout"`
+			Layout         string `db:"layout"`
+			CreatedAt      int64  `db:"created_at"`
+		}
+	)
+	if err := s.db.Select(&records, query, contract, limit); err != nil {
+		return nil, err
+	}
+	log := make([]common.LayoutAudit, 0, len(records))
+	for _, r := range records {
+		log = append(log, common.LayoutAudit(r))
+	}
+	return log, nil
+}
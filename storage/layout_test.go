@@ -0,0 +1,33 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+
+	ethereum "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorageLayout(t *testing.T) {
+	s, err := NewStorage(filepath.Join(t.TempDir(), "layout_test.db"))
+	require.NoError(t, err)
+	contract := ethereum.HexToAddress("0xbc5b5c036eb41a1a85af0b4da13d56420e8a0a92")
+	require.NoError(t, s.StoreStorageLayout(contract, `{"storage":[],"types":{}}`, "alice"))
+
+	layout, err := s.GetStorageLayout(contract)
+	require.NoError(t, err)
+	require.Equal(t, `{"storage":[],"types":{}}`, layout)
+
+	layout, err = s.GetStorageLayout(ethereum.HexToAddress("0x0000000000000000000000000000000000000001"))
+	require.NoError(t, err)
+	require.Empty(t, layout)
+
+	require.NoError(t, s.StoreStorageLayout(contract, `{"storage":[{"label":"owner"}],"types":{}}`, "bob"))
+	log, err := s.GetLayoutAuditLog(contract.Hex(), 10)
+	require.NoError(t, err)
+	require.Len(t, log, 2)
+	require.Equal(t, "bob", log[0].Actor)
+	require.Equal(t, `{"storage":[],"types":{}}`, log[0].PreviousLayout)
+	require.Equal(t, "alice", log[1].Actor)
+	require.Empty(t, log[1].PreviousLayout)
+}
@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+
+	"github.com/KyberNetwork/contract-caller/common"
+)
+
+type queryRecord struct {
+	ID          string `db:"id"`
+	Name        string `db:"name"`
+	Network     string `db:"network"`
+	Contract    string `db:"contract"`
+	Method      string `db:"method"`
+	Params      string `db:"params"`
+	BlockNumber string `db:"block_number"`
+	Tags        string `db:"tags"`
+	CreatedAt   int64  `db:"created_at"`
+}
+
+// tags are stored as ",tag1,tag2," so a single tag can be matched with LIKE '%,tag,%'
+func joinTags(tags []string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	return "," + strings.Join(tags, ",") + ","
+}
+
+func splitTags(tags string) []string {
+	tags = strings.Trim(tags, ",")
+	if tags == "" {
+		return []string{}
+	}
+	return strings.Split(tags, ",")
+}
+
+func (r queryRecord) toQuery() (common.SavedQuery, error) {
+	var params map[string]interface{}
+	if err := json.Unmarshal([]byte(r.Params), &params); err != nil {
+		return common.SavedQuery{}, err
+	}
+	return common.SavedQuery{
+		ID:          r.ID,
+		Name:        r.Name,
+		Network:     r.Network,
+		Contract:    r.Contract,
+		Method:      r.Method,
+		Params:      params,
+		BlockNumber: r.BlockNumber,
+		Tags:        splitTags(r.Tags),
+		CreatedAt:   r.CreatedAt,
+	}, nil
+}
+
+// StoreQuery inserts or replaces a saved query
+func (s *Storage) StoreQuery(q common.SavedQuery) error {
+	var (
+		query = `REPLACE INTO "queries" (id, name, network, contract, method, params, block_number, tags, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);`
+	)
+	params, err := json.Marshal(q.Params)
+	if err != nil {
+		return err
+	}
+	queryX, err := s.db.Preparex(query)
+	if err != nil {
+		return err
+	}
+	if _, err := queryX.Exec(q.ID, q.Name, q.Network, q.Contract, q.Method, string(params), q.BlockNumber,
+		joinTags(q.Tags), q.CreatedAt); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetQuery returns saved query by id, nil if not found
+func (s *Storage) GetQuery(id string) (*common.SavedQuery, error) {
+	var (
+		query  = `SELECT * FROM "queries" WHERE id=$1;`
+		record queryRecord
+	)
+	if err := s.db.Get(&record, query, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	q, err := record.toQuery()
+	if err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike escapes wildcards of LIKE so s is matched literally, with '\' as escape character
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+// SearchQueries returns saved queries having given tag (if not empty) and whose name, contract or method
+// contains text (if not empty), newest first
+func (s *Storage) SearchQueries(tag, text string) ([]common.SavedQuery, error) {
+	var (
+		query = `SELECT * FROM "queries"
+			WHERE ($1 = '' OR tags LIKE '%,' || $1 || ',%' ESCAPE '\')
+			AND ($2 = '' OR name LIKE '%' || $2 || '%' ESCAPE '\' OR contract LIKE '%' || $2 || '%' ESCAPE '\'
+				OR method LIKE '%' || $2 || '%' ESCAPE '\')
+			ORDER BY created_at DESC;`
+		records []queryRecord
+	)
+	if err := s.db.Select(&records, query, escapeLike(tag), escapeLike(text)); err != nil {
+		return nil, err
+	}
+	queries := make([]common.SavedQuery, 0, len(records))
+	for _, r := range records {
+		q, err := r.toQuery()
+		if err != nil {
+			return nil, err
+		}
+		queries = append(queries, q)
+	}
+	return queries, nil
+}
+
+// DeleteQuery ...
+func (s *Storage) DeleteQuery(id string) error {
+	var (
+		query = `DELETE FROM "queries" WHERE id=$1;`
+	)
+	if _, err := s.db.Exec(query, id); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/KyberNetwork/contract-caller/common"
+)
+
+func TestQueries(t *testing.T) {
+	s, err := NewStorage(filepath.Join(t.TempDir(), "db_test.db"))
+	require.NoError(t, err)
+	q := common.SavedQuery{
+		ID:          "test-query",
+		Name:        "reserve of pair",
+		Network:     common.EthereumMainnet,
+		Contract:    "0xbc5b5c036eb41a1a85af0b4da13d56420e8a0a92",
+		Method:      "getReserves",
+		Params:      map[string]interface{}{},
+		BlockNumber: "11000000",
+		Tags:        []string{"uniswap", "pair"},
+		CreatedAt:   1,
+	}
+	require.NoError(t, s.StoreQuery(q))
+
+	stored, err := s.GetQuery(q.ID)
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	require.Equal(t, q, *stored)
+
+	found, err := s.SearchQueries("pair", "reserve")
+	require.NoError(t, err)
+	require.Contains(t, found, q)
+	found, err = s.SearchQueries("pai", "")
+	require.NoError(t, err)
+	require.NotContains(t, found, q)
+
+	require.NoError(t, s.DeleteQuery(q.ID))
+	stored, err = s.GetQuery(q.ID)
+	require.NoError(t, err)
+	require.Nil(t, stored)
+}
+
+func TestSearchQueriesWildcards(t *testing.T) {
+	s, err := NewStorage(filepath.Join(t.TempDir(), "query_test.db"))
+	require.NoError(t, err)
+	literal := common.SavedQuery{ID: "literal", Name: "100% of pool_v2", Params: map[string]interface{}{},
+		Tags: []string{"lp_v2"}, CreatedAt: 2}
+	plain := common.SavedQuery{ID: "plain", Name: "reserve", Params: map[string]interface{}{},
+		Tags: []string{"lpxv2"}, CreatedAt: 1}
+	require.NoError(t, s.StoreQuery(literal))
+	require.NoError(t, s.StoreQuery(plain))
+
+	for _, text := range []string{"%", "_", "0% of pool_"} {
+		found, err := s.SearchQueries("", text)
+		require.NoError(t, err)
+		require.Equal(t, []common.SavedQuery{literal}, found, text)
+	}
+	found, err := s.SearchQueries("lp_v2", "")
+	require.NoError(t, err)
+	require.Equal(t, []common.SavedQuery{literal}, found)
+	found, err = s.SearchQueries("", `\`)
+	require.NoError(t, err)
+	require.Empty(t, found)
+}
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	ethereum "github.com/ethereum/go-ethereum/common"
+
+	"github.com/KyberNetwork/contract-caller/common"
+)
+
+type sourceRecord struct {
+	Contract         string `db:"contract"`
+	Name             string `db:"name"`
+	CompilerVersion  string `db:"compiler_version"`
+	OptimizationUsed bool   `db:"optimization_used"`
+	Runs             int    `db:"runs"`
+	EVMVersion       string `db:"evm_version"`
+	License          string `db:"license"`
+	Proxy            bool   `db:"proxy"`
+	Implementation   string `db:"implementation"`
+	Docs             string `db:"docs"`
+}
+
+func (r sourceRecord) toContractSource() (common.ContractSource, error) {
+	var docs map[string]string
+	if err := json.Unmarshal([]byte(r.Docs), &docs); err != nil {
+		return common.ContractSource{}, err
+	}
+	return common.ContractSource{
+		Address:          r.Contract,
+		Name:             r.Name,
+		CompilerVersion:  r.CompilerVersion,
+		OptimizationUsed: r.OptimizationUsed,
+		Runs:             r.Runs,
+		EVMVersion:       r.EVMVersion,
+		License:          r.License,
+		Proxy:            r.Proxy,
+		Implementation:   r.Implementation,
+		Docs:             docs,
+	}, nil
+}
+
+// StoreContractSource inserts or replaces source metadata of a contract
+func (s *Storage) StoreContractSource(source common.ContractSource) error {
+	var (
+		query = `REPLACE INTO "contract_sources" (contract, name, compiler_version, optimization_used, runs,
+			evm_version, license, proxy, implementation, docs) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);`
+	)
+	docs, err := json.Marshal(source.Docs)
+	if err != nil {
+		return err
+	}
+	queryX, err := s.db.Preparex(query)
+	if err != nil {
+		return err
+	}
+	if _, err := queryX.Exec(ethereum.HexToAddress(source.Address).Hex(), source.Name, source.CompilerVersion,
+		source.OptimizationUsed, source.Runs, source.EVMVersion, source.License, source.Proxy,
+		source.Implementation, string(docs)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetContractSource returns source metadata of a contract, nil if not found
+func (s *Storage) GetContractSource(contract ethereum.Address) (*common.ContractSource, error) {
+	var (
+		query  = `SELECT * FROM "contract_sources" WHERE contract=$1;`
+		record sourceRecord
+	)
+	queryX, err := s.db.Preparex(query)
+	if err != nil {
+		return nil, err
+	}
+	if err := queryX.Get(&record, contract.Hex()); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	source, err := record.toContractSource()
+	if err != nil {
+		return nil, err
+	}
+	return &source, nil
+}
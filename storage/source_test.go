@@ -0,0 +1,35 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+
+	ethereum "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KyberNetwork/contract-caller/common"
+)
+
+func TestContractSource(t *testing.T) {
+	s, err := NewStorage(filepath.Join(t.TempDir(), "db_test.db"))
+	require.NoError(t, err)
+	source := common.ContractSource{
+		Address:          "0xBc5B5c036Eb41A1A85AF0B4Da13D56420e8A0a92",
+		Name:             "Token",
+		CompilerVersion:  "v0.6.6+commit.6c089d02",
+		OptimizationUsed: true,
+		Runs:             200,
+		EVMVersion:       "istanbul",
+		License:          "MIT",
+		Docs:             map[string]string{"balanceOf": "Returns balance of owner"},
+	}
+	require.NoError(t, s.StoreContractSource(source))
+
+	stored, err := s.GetContractSource(ethereum.HexToAddress(source.Address))
+	require.NoError(t, err)
+	require.Equal(t, source, *stored)
+
+	stored, err = s.GetContractSource(ethereum.HexToAddress("0x0000000000000000000000000000000000000001"))
+	require.NoError(t, err)
+	require.Nil(t, stored)
+}
@@ -2,11 +2,15 @@ package storage
 
 import (
 	"database/sql"
+	"fmt"
+	"time"
 
 	ethereum "github.com/ethereum/go-ethereum/common"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/mattn/go-sqlite3" // sqlite driver
 	"go.uber.org/zap"
+
+	"github.com/KyberNetwork/contract-caller/common"
 )
 
 // Storage ...
@@ -35,11 +39,123 @@ func (s *Storage) initDB() error {
 			contract TEXT PRIMARY KEY,
 			abi      TEXT NOT NULL
 		);
+		CREATE TABLE IF NOT EXISTS "alerts" (
+			id             INTEGER PRIMARY KEY AUTOINCREMENT,
+			name           TEXT NOT NULL,
+			contract       TEXT NOT NULL,
+			network        TEXT NOT NULL,
+			method         TEXT NOT NULL,
+			params         TEXT NOT NULL,
+			condition      TEXT NOT NULL,
+			threshold      TEXT NOT NULL,
+			webhook_url    TEXT NOT NULL,
+			webhook_format TEXT NOT NULL,
+			last_value     TEXT NOT NULL DEFAULT '',
+			triggered      BOOLEAN NOT NULL DEFAULT FALSE
+		);
+		CREATE TABLE IF NOT EXISTS "queries" (
+			id           TEXT PRIMARY KEY,
+			name         TEXT NOT NULL,
+			network      TEXT NOT NULL,
+			contract     TEXT NOT NULL,
+			method       TEXT NOT NULL,
+			params       TEXT NOT NULL,
+			block_number TEXT NOT NULL,
+			tags         TEXT NOT NULL,
+			created_at   INTEGER NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS "history" (
+			id             INTEGER PRIMARY KEY AUTOINCREMENT,
+			contract       TEXT NOT NULL,
+			abi            TEXT NOT NULL,
+			method         TEXT NOT NULL,
+			params         TEXT NOT NULL,
+			block_number   TEXT NOT NULL,
+			resolved_block INTEGER NOT NULL,
+			node           TEXT NOT NULL,
+			result         TEXT NOT NULL,
+			err            TEXT NOT NULL,
+			latency_ms     INTEGER NOT NULL,
+			created_at     INTEGER NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS "history_contract_method" ON "history" (contract, method, created_at);
+		CREATE TABLE IF NOT EXISTS "console_history" (
+			id         INTEGER PRIMARY KEY AUTOINCREMENT,
+			line       TEXT NOT NULL,
+			created_at INTEGER NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS "contract_sources" (
+			contract          TEXT PRIMARY KEY,
+			name              TEXT NOT NULL,
+			compiler_version  TEXT NOT NULL,
+			optimization_used INTEGER NOT NULL,
+			runs              INTEGER NOT NULL,
+			evm_version       TEXT NOT NULL,
+			license           TEXT NOT NULL,
+			proxy             INTEGER NOT NULL,
+			implementation    TEXT NOT NULL,
+			docs              TEXT NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS "storage_layouts" (
+			contract TEXT PRIMARY KEY,
+			layout   TEXT NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS "api_keys" (
+			id          INTEGER PRIMARY KEY AUTOINCREMENT,
+			name        TEXT NOT NULL,
+			prefix      TEXT NOT NULL,
+			role        TEXT NOT NULL DEFAULT 'viewer',
+			key_hash    TEXT NOT NULL UNIQUE,
+			rate_limit  REAL NOT NULL,
+			daily_quota INTEGER NOT NULL,
+			revoked     BOOLEAN NOT NULL DEFAULT FALSE,
+			created_at  INTEGER NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS "abi_audit_log" (
+			id           INTEGER PRIMARY KEY AUTOINCREMENT,
+			contract     TEXT NOT NULL,
+			actor        TEXT NOT NULL,
+			previous_abi TEXT NOT NULL,
+			abi          TEXT NOT NULL,
+			created_at   INTEGER NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS "abi_audit_log_contract" ON "abi_audit_log" (contract, created_at);
+		CREATE TABLE IF NOT EXISTS "layout_audit_log" (
+			id              INTEGER PRIMARY KEY AUTOINCREMENT,
+			contract        TEXT NOT NULL,
+			actor           TEXT NOT NULL,
+			previous_layout TEXT NOT NULL,
+			layout          TEXT NOT NULL,
+			created_at      INTEGER NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS "layout_audit_log_contract" ON "layout_audit_log" (contract, created_at);
+		CREATE TABLE IF NOT EXISTS "abi_versions" (
+			id         INTEGER PRIMARY KEY AUTOINCREMENT,
+			contract   TEXT NOT NULL,
+			abi        TEXT NOT NULL,
+			source     TEXT NOT NULL,
+			author     TEXT NOT NULL,
+			created_at INTEGER NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS "abi_versions_contract" ON "abi_versions" (contract, id);
+		CREATE TABLE IF NOT EXISTS "api_key_usage" (
+			key_id INTEGER NOT NULL,
+			day    TEXT NOT NULL,
+			count  INTEGER NOT NULL,
+			PRIMARY KEY (key_id, day)
+		);
 	`
 	if _, err := s.db.Exec(schema); err != nil {
 		return err
 	}
-	return nil
+	if err := s.migrate(); err != nil {
+		return err
+	}
+	// abis stored before versions are kept as their first version
+	_, err := s.db.Exec(`INSERT INTO "abi_versions" (contract, abi, source, author, created_at)
+		SELECT contract, abi, $1, '', $2 FROM "abis"
+		WHERE contract NOT IN (SELECT contract FROM "abi_versions");`, common.ABISourceUser, time.Now().Unix())
+	return err
 }
 
 // GetContractABI return abi of given contract in db
@@ -61,17 +177,103 @@ func (s *Storage) GetContractABI(contract ethereum.Address) (string, error) {
 	return abi, nil
 }
 
-// StoreContractABI ...
-func (s *Storage) StoreContractABI(contract ethereum.Address, abi string) error {
+// migrations add columns to tables created by older versions, tables created now already have them
+var migrations = []struct {
+	table      string
+	column     string
+	definition string
+}{
+	{"api_keys", "role", `TEXT NOT NULL DEFAULT 'viewer'`},
+}
+
+// migrate applies migrations whose column does not exist yet
+func (s *Storage) migrate() error {
+	for _, m := range migrations {
+		var n int
+		if err := s.db.Get(&n, `SELECT COUNT(*) FROM pragma_table_info($1) WHERE name=$2;`, m.table, m.column); err != nil {
+			return err
+		}
+		if n != 0 {
+			continue
+		}
+		if _, err := s.db.Exec(fmt.Sprintf(`ALTER TABLE "%s" ADD COLUMN %s %s;`, m.table, m.column, m.definition)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StoreContractABI stores abi of a contract as a new version from source, and records the write with actor and
+// previous abi in the audit log
+func (s *Storage) StoreContractABI(contract ethereum.Address, abi, source, actor string) error {
 	var (
-		query = `REPLACE INTO "abis" (contract, abi) VALUES ($1, $2);`
+		previousQuery = `SELECT abi FROM "abis" WHERE contract=$1;`
+		query         = `REPLACE INTO "abis" (contract, abi) VALUES ($1, $2);`
+		auditQuery    = `INSERT INTO "abi_audit_log" (contract, actor, previous_abi, abi, created_at)
+			VALUES ($1, $2, $3, $4, $5);`
+		previous string
 	)
-	queryX, err := s.db.Preparex(query)
+	tx, err := s.db.Beginx()
 	if err != nil {
 		return err
 	}
-	if _, err := queryX.Exec(contract.Hex(), abi); err != nil {
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	if err := tx.Get(&previous, previousQuery, contract.Hex()); err != nil && err != sql.ErrNoRows {
 		return err
 	}
-	return nil
+	if _, err := tx.Exec(query, contract.Hex(), abi); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(auditQuery, contract.Hex(), actor, previous, abi, time.Now().Unix()); err != nil {
+		return err
+	}
+	if err := addABIVersion(tx, contract.Hex(), abi, source, actor); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetABIAuditLog returns latest abi writes, of a contract if not empty
+func (s *Storage) GetABIAuditLog(contract string, limit int) ([]common.ABIAudit, error) {
+	var (
+		query = `SELECT id, contract, actor, previous_abi, abi, created_at FROM "abi_audit_log"
+			WHERE ($1 = '' OR contract = $1) ORDER BY id DESC LIMIT $2;`
+		records []struct {
+			ID          int64  `db:"id"`
+			Contract    string `db:"contract"`
+			Actor       string `db:"actor"`
+			PreviousABI string `db:"previous_abi"`
+			ABI         string `db:"abi"`
+			CreatedAt   int64  `db:"created_at"`
+		}
+	)
+	if err := s.db.Select(&records, query, contract, limit); err != nil {
+		return nil, err
+	}
+	log := make([]common.ABIAudit, 0, len(records))
+	for _, r := range records {
+		log = append(log, common.ABIAudit(r))
+	}
+	return log, nil
+}
+
+// GetContractABIs returns all stored abis by contract
+func (s *Storage) GetContractABIs() (map[string]string, error) {
+	var (
+		query   = `SELECT contract, abi FROM "abis" ORDER BY contract;`
+		records []struct {
+			Contract string `db:"contract"`
+			ABI      string `db:"abi"`
+		}
+	)
+	if err := s.db.Select(&records, query); err != nil {
+		return nil, err
+	}
+	result := make(map[string]string, len(records))
+	for _, r := range records {
+		result[r.Contract] = r.ABI
+	}
+	return result, nil
 }
@@ -1,30 +1,70 @@
 package storage
 
 import (
+	"path/filepath"
 	"testing"
 
-	"github.com/ethereum/go-ethereum/common"
+	ethereum "github.com/ethereum/go-ethereum/common"
 	"github.com/stretchr/testify/require"
+
+	"github.com/KyberNetwork/contract-caller/common"
 )
 
 func TestWriteAndRead(t *testing.T) {
-	s, err := NewStorage("db_test.db")
+	s, err := NewStorage(filepath.Join(t.TempDir(), "db_test.db"))
 	require.NoError(t, err)
 	var (
-		contract = common.HexToAddress("0xbc5b5c036eb41a1a85af0b4da13d56420e8a0a92")
+		contract = ethereum.HexToAddress("0xbc5b5c036eb41a1a85af0b4da13d56420e8a0a92")
 		abi      = "abi"
 		newABI   = "newABI"
 	)
 
-	err = s.StoreContractABI(contract, abi)
+	err = s.StoreContractABI(contract, abi, common.ABISourceUser, "alice")
 	require.NoError(t, err)
 	sABI, err := s.GetContractABI(contract)
 	require.NoError(t, err)
 	require.Equal(t, abi, sABI)
 
-	err = s.StoreContractABI(contract, newABI)
+	err = s.StoreContractABI(contract, newABI, common.ABISourceArtifact, "bob")
 	require.NoError(t, err)
 	sABI, err = s.GetContractABI(contract)
 	require.NoError(t, err)
 	require.Equal(t, newABI, sABI)
+
+	abis, err := s.GetContractABIs()
+	require.NoError(t, err)
+	require.Equal(t, newABI, abis[contract.Hex()])
+
+	log, err := s.GetABIAuditLog(contract.Hex(), 1)
+	require.NoError(t, err)
+	require.Len(t, log, 1)
+	require.Equal(t, "bob", log[0].Actor)
+	require.Equal(t, abi, log[0].PreviousABI)
+	require.Equal(t, newABI, log[0].ABI)
+
+	versions, err := s.GetABIVersions(contract.Hex())
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	for i, expected := range []common.ABIVersion{
+		{Contract: contract.Hex(), ABI: newABI, Source: common.ABISourceArtifact, Author: "bob"},
+		{Contract: contract.Hex(), ABI: abi, Source: common.ABISourceUser, Author: "alice"},
+	} {
+		require.NotZero(t, versions[i].ID)
+		require.NotZero(t, versions[i].CreatedAt)
+		expected.ID, expected.CreatedAt = versions[i].ID, versions[i].CreatedAt
+		require.Equal(t, expected, versions[i])
+	}
+
+	// storing the latest abi again adds no version
+	require.NoError(t, s.StoreContractABI(contract, newABI, common.ABISourceEtherscan, "carol"))
+	again, err := s.GetABIVersions(contract.Hex())
+	require.NoError(t, err)
+	require.Equal(t, versions, again)
+
+	v, err := s.GetABIVersion(contract.Hex(), versions[1].ID)
+	require.NoError(t, err)
+	require.Equal(t, abi, v.ABI)
+	v, err = s.GetABIVersion(contract.Hex(), -1)
+	require.NoError(t, err)
+	require.Nil(t, v)
 }